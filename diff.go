@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around every change
+const diffContext = 3
+
+type diffLine struct {
+	// kind is ' ' for an unchanged line, '-' for a removed one and '+' for an added one
+	kind byte
+	text string
+	// oldLine and newLine are the 0-based positions in the old and the new text where the line is
+	oldLine int
+	newLine int
+}
+
+// diff compares two texts line by line and returns the changes in the unified format,
+// as printed by diff -u. It returns an empty string if the texts are the same.
+func diff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	lines := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		// the hunk grows while the next change is close enough to share the context
+		end := start
+
+		for next := start; next < len(lines) && next <= end+2*diffContext; next++ {
+			if lines[next].kind != ' ' {
+				end = next
+			}
+		}
+
+		from := max(start-diffContext, 0)
+		to := min(end+diffContext+1, len(lines))
+
+		writeHunk(&b, lines[from:to])
+
+		start = to
+	}
+
+	return b.String()
+}
+
+func writeHunk(b *strings.Builder, hunk []diffLine) {
+	oldCount, newCount := 0, 0
+
+	for _, line := range hunk {
+		if line.kind != '+' {
+			oldCount++
+		}
+
+		if line.kind != '-' {
+			newCount++
+		}
+	}
+
+	// the positions are 1-based, an empty range points to the line before it
+	oldStart := hunk[0].oldLine
+	newStart := hunk[0].newLine
+
+	if oldCount > 0 {
+		oldStart++
+	}
+
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, line := range hunk {
+		fmt.Fprintf(b, "%c%s\n", line.kind, line.text)
+	}
+}
+
+// diffLines finds the longest common subsequence of the lines, everything else was removed or added.
+// The common start and end are skipped, the rest is aligned with Hirschberg's algorithm, which
+// needs memory proportional to the number of lines, not to their product.
+func diffLines(oldLines, newLines []string) []diffLine {
+	lines := []diffLine{}
+	prefix := 0
+
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		lines = append(lines, diffLine{' ', oldLines[prefix], prefix, prefix})
+		prefix++
+	}
+
+	suffix := 0
+
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	lines = align(lines, oldLines, newLines, prefix, len(oldLines)-suffix, prefix, len(newLines)-suffix)
+
+	for k := suffix; k > 0; k-- {
+		i, j := len(oldLines)-k, len(newLines)-k
+		lines = append(lines, diffLine{' ', oldLines[i], i, j})
+	}
+
+	return lines
+}
+
+// align appends the alignment of oldLines[oldFrom:oldTo] with newLines[newFrom:newTo]. The old lines
+// are split in half and the new lines where the common subsequences of both halves are the longest.
+func align(lines []diffLine, oldLines, newLines []string, oldFrom, oldTo, newFrom, newTo int) []diffLine {
+	switch {
+	case oldFrom == oldTo:
+		for j := newFrom; j < newTo; j++ {
+			lines = append(lines, diffLine{'+', newLines[j], oldFrom, j})
+		}
+
+		return lines
+	case newFrom == newTo:
+		for i := oldFrom; i < oldTo; i++ {
+			lines = append(lines, diffLine{'-', oldLines[i], i, newFrom})
+		}
+
+		return lines
+	case oldTo-oldFrom == 1:
+		for j := newFrom; j < newTo; j++ {
+			if oldLines[oldFrom] == newLines[j] {
+				lines = align(lines, oldLines, newLines, oldFrom, oldFrom, newFrom, j)
+				lines = append(lines, diffLine{' ', oldLines[oldFrom], oldFrom, j})
+
+				return align(lines, oldLines, newLines, oldTo, oldTo, j+1, newTo)
+			}
+		}
+
+		lines = append(lines, diffLine{'-', oldLines[oldFrom], oldFrom, newFrom})
+
+		return align(lines, oldLines, newLines, oldTo, oldTo, newFrom, newTo)
+	}
+
+	middle := (oldFrom + oldTo) / 2
+	forward := commonLengths(oldLines, newLines, oldFrom, middle, newFrom, newTo, 1)
+	backward := commonLengths(oldLines, newLines, oldTo-1, middle-1, newTo-1, newFrom-1, -1)
+
+	split, best := newFrom, -1
+
+	for j := newFrom; j <= newTo; j++ {
+		if length := forward[j-newFrom] + backward[newTo-j]; length > best {
+			split, best = j, length
+		}
+	}
+
+	lines = align(lines, oldLines, newLines, oldFrom, middle, newFrom, split)
+
+	return align(lines, oldLines, newLines, middle, oldTo, split, newTo)
+}
+
+// commonLengths returns the lengths of the longest common subsequences of the old lines from oldFrom
+// to oldTo and the first k new lines from newFrom, for every k. With the step -1 it goes backwards.
+func commonLengths(oldLines, newLines []string, oldFrom, oldTo, newFrom, newTo, step int) []int {
+	count := (newTo - newFrom) * step
+	previous := make([]int, count+1)
+	current := make([]int, count+1)
+
+	for i := oldFrom; i != oldTo; i += step {
+		for k := 1; k <= count; k++ {
+			if oldLines[i] == newLines[newFrom+(k-1)*step] {
+				current[k] = previous[k-1] + 1
+			} else {
+				current[k] = max(previous[k], current[k-1])
+			}
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous
+}
+
+// splitLines splits the text into lines, the last line doesn't need a line terminator
+func splitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
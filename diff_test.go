@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	diffTests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{
+			name:     "same",
+			old:      "a\nb\n",
+			new:      "a\nb\n",
+			expected: "",
+		},
+		{
+			name:     "changed line",
+			old:      "a\nb\nc\n",
+			new:      "a\nB\nc\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:     "separate hunks",
+			old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			new:      "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -7,4 +8,3 @@\n 7\n 8\n 9\n-10\n",
+		},
+		{
+			name:     "added to empty",
+			old:      "",
+			new:      "a\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range diffTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diff("old", "new", tt.old, tt.new); got != tt.expected {
+				t.Errorf("got\n%s\nexpected\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// the alignment keeps the most lines in common and gives back both texts
+func TestDiffLines(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 200; n++ {
+		oldLines := randomLines(random)
+		newLines := randomLines(random)
+
+		var oldText, newText []string
+		common := 0
+
+		for _, line := range diffLines(oldLines, newLines) {
+			if line.kind != '+' {
+				oldText = append(oldText, line.text)
+			}
+
+			if line.kind != '-' {
+				newText = append(newText, line.text)
+			}
+
+			if line.kind == ' ' {
+				common++
+			}
+		}
+
+		if strings.Join(oldText, ",") != strings.Join(oldLines, ",") || strings.Join(newText, ",") != strings.Join(newLines, ",") {
+			t.Fatalf("%v and %v are not reconstructed, got %v and %v", oldLines, newLines, oldText, newText)
+		}
+
+		if expected := lcsLength(oldLines, newLines); common != expected {
+			t.Fatalf("%v and %v have %d lines in common, got %d", oldLines, newLines, expected, common)
+		}
+	}
+}
+
+func TestDiffLargeFile(t *testing.T) {
+	oldLines := make([]string, 5000)
+	newLines := make([]string, 0, len(oldLines))
+
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("line %d", i)
+
+		if i%1000 != 500 {
+			newLines = append(newLines, oldLines[i])
+		}
+	}
+
+	got := diff("old", "new", strings.Join(oldLines, "\n")+"\n", strings.Join(newLines, "\n")+"\n")
+
+	if hunks := strings.Count(got, "@@ -"); hunks != 5 {
+		t.Errorf("got %d hunks, expected 5", hunks)
+	}
+}
+
+func randomLines(random *rand.Rand) []string {
+	lines := make([]string, random.Intn(12))
+
+	for i := range lines {
+		lines[i] = string(rune('a' + random.Intn(4)))
+	}
+
+	return lines
+}
+
+func lcsLength(a, b []string) int {
+	lengths := make([][]int, len(a)+1)
+
+	for i := range lengths {
+		lengths[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	return lengths[0][0]
+}
@@ -0,0 +1,282 @@
+package golox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AstPrinter renders the syntax tree either as Lisp-like S-expressions, eg. (* (- 1) (group 2)),
+// or as an indented tree with one node per line
+type AstPrinter struct {
+	// result holds the output of the last visited statement, the statement visitors only return errors
+	result *astNode
+}
+
+// astNode is the intermediate form, both outputs are rendered from it
+type astNode struct {
+	label    string
+	children []*astNode
+	// atoms are rendered without parentheses, eg. identifiers and literals
+	atom bool
+}
+
+func atom(label string) *astNode {
+	return &astNode{label: label, atom: true}
+}
+
+func NewAstPrinter() *AstPrinter {
+	return &AstPrinter{}
+}
+
+// Print renders each statement as an S-expression on its own line
+func (p *AstPrinter) Print(stmts []IStmt) string {
+	lines := make([]string, 0, len(stmts))
+
+	for _, stmt := range stmts {
+		lines = append(lines, p.stmt(stmt).sexpr())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// PrintTree renders the statements as an indented tree, the children are indented by two spaces
+func (p *AstPrinter) PrintTree(stmts []IStmt) string {
+	var b strings.Builder
+
+	for _, stmt := range stmts {
+		p.stmt(stmt).tree(&b, 0)
+	}
+
+	return b.String()
+}
+
+func (p *AstPrinter) PrintExpr(expr IExpr) string {
+	return p.expr(expr).sexpr()
+}
+
+func (n *astNode) sexpr() string {
+	if n.atom {
+		return n.label
+	}
+
+	parts := make([]string, 0, len(n.children)+1)
+	parts = append(parts, n.label)
+
+	for _, child := range n.children {
+		parts = append(parts, child.sexpr())
+	}
+
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (n *astNode) tree(b *strings.Builder, depth int) {
+	fmt.Fprintf(b, "%s%s\n", strings.Repeat("  ", depth), n.label)
+
+	for _, child := range n.children {
+		child.tree(b, depth+1)
+	}
+}
+
+func (p *AstPrinter) stmt(stmt IStmt) *astNode {
+	stmt.Accept(p)
+
+	return p.result
+}
+
+func (p *AstPrinter) expr(expr IExpr) *astNode {
+	value, _ := expr.Accept(p)
+
+	return value.(*astNode)
+}
+
+func (p *AstPrinter) parenthesize(name string, parts ...*astNode) *astNode {
+	return &astNode{label: name, children: parts}
+}
+
+func (p *AstPrinter) stmts(stmts []IStmt) []*astNode {
+	parts := make([]*astNode, 0, len(stmts))
+
+	for _, stmt := range stmts {
+		parts = append(parts, p.stmt(stmt))
+	}
+
+	return parts
+}
+
+func (p *AstPrinter) function(keyword string, stmt *FunctionStmt) *astNode {
+	params := make([]string, 0, len(stmt.params))
+
+	for _, param := range stmt.params {
+		params = append(params, param.lexeme)
+	}
+
+	parts := append([]*astNode{atom(stmt.name.lexeme), atom("(" + strings.Join(params, " ") + ")")}, p.stmts(stmt.body)...)
+
+	return p.parenthesize(keyword, parts...)
+}
+
+// VisitBlockStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitBlockStmt(stmt *BlockStmt) error {
+	p.result = p.parenthesize("block", p.stmts(stmt.statements)...)
+
+	return nil
+}
+
+// VisitClassStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitClassStmt(stmt *ClassStmt) error {
+	parts := []*astNode{atom(stmt.name.lexeme)}
+
+	if stmt.superclass != nil {
+		parts = append(parts, atom("<"), atom(stmt.superclass.name.lexeme))
+	}
+
+	for _, method := range stmt.methods {
+		parts = append(parts, p.function("method", method))
+	}
+
+	p.result = p.parenthesize("class", parts...)
+
+	return nil
+}
+
+// VisitExpressionStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitExpressionStmt(stmt *ExpressionStmt) error {
+	p.result = p.parenthesize(";", p.expr(stmt.expr))
+
+	return nil
+}
+
+// VisitFunctionStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitFunctionStmt(stmt *FunctionStmt) error {
+	p.result = p.function("fun", stmt)
+
+	return nil
+}
+
+// VisitIfStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitIfStmt(stmt *IfStmt) error {
+	parts := []*astNode{p.expr(stmt.condition), p.stmt(stmt.thenBranch)}
+
+	if stmt.elseBranch != nil {
+		parts = append(parts, p.stmt(stmt.elseBranch))
+	}
+
+	p.result = p.parenthesize("if", parts...)
+
+	return nil
+}
+
+// VisitPrintStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitPrintStmt(stmt *PrintStmt) error {
+	p.result = p.parenthesize("print", p.expr(stmt.expr))
+
+	return nil
+}
+
+// VisitReturnStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitReturnStmt(stmt *ReturnStmt) error {
+	if stmt.value == nil {
+		p.result = p.parenthesize("return")
+	} else {
+		p.result = p.parenthesize("return", p.expr(stmt.value))
+	}
+
+	return nil
+}
+
+// VisitVarStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitVarStmt(stmt *VarStmt) error {
+	if stmt.initializer == nil {
+		p.result = p.parenthesize("var", atom(stmt.name.lexeme))
+	} else {
+		p.result = p.parenthesize("var", atom(stmt.name.lexeme), p.expr(stmt.initializer))
+	}
+
+	return nil
+}
+
+// VisitWhileStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitWhileStmt(stmt *WhileStmt) error {
+	p.result = p.parenthesize("while", p.expr(stmt.condition), p.stmt(stmt.body))
+
+	return nil
+}
+
+// VisitForStmt implements IStmtVisitor.
+func (p *AstPrinter) VisitForStmt(stmt *ForStmt) error {
+	// the tree shows what is executed
+	p.result = p.stmt(stmt.desugared)
+
+	return nil
+}
+
+func (p *AstPrinter) VisitAssignExpr(expr *AssignExpr) (any, error) {
+	return p.parenthesize("=", atom(expr.name.lexeme), p.expr(expr.value)), nil
+}
+
+func (p *AstPrinter) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
+	return p.parenthesize(expr.operator.lexeme, p.expr(expr.left), p.expr(expr.right)), nil
+}
+
+func (p *AstPrinter) VisitCallExpr(expr *CallExpr) (any, error) {
+	parts := []*astNode{p.expr(expr.callee)}
+
+	for _, argument := range expr.arguments {
+		parts = append(parts, p.expr(argument))
+	}
+
+	return p.parenthesize("call", parts...), nil
+}
+
+func (p *AstPrinter) VisitGetExpr(expr *GetExpr) (any, error) {
+	return p.parenthesize(".", p.expr(expr.object), atom(expr.name.lexeme)), nil
+}
+
+func (p *AstPrinter) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
+	return p.parenthesize("group", p.expr(expr.expression)), nil
+}
+
+func (p *AstPrinter) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
+	return atom(formatLiteral(expr.value)), nil
+}
+
+func (p *AstPrinter) VisitInterpolationExpr(expr *InterpolationExpr) (any, error) {
+	// like the for loop, the tree shows what is evaluated
+	return p.expr(expr.desugared), nil
+}
+
+func (p *AstPrinter) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
+	return p.parenthesize(expr.operator.lexeme, p.expr(expr.left), p.expr(expr.right)), nil
+}
+
+func (p *AstPrinter) VisitSetExpr(expr *SetExpr) (any, error) {
+	return p.parenthesize("=", p.parenthesize(".", p.expr(expr.object), atom(expr.name.lexeme)), p.expr(expr.value)), nil
+}
+
+func (p *AstPrinter) VisitSuperExpr(expr *SuperExpr) (any, error) {
+	return p.parenthesize("super", atom(expr.method.lexeme)), nil
+}
+
+func (p *AstPrinter) VisitThisExpr(expr *ThisExpr) (any, error) {
+	return atom("this"), nil
+}
+
+func (p *AstPrinter) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
+	return p.parenthesize(expr.operator.lexeme, p.expr(expr.right)), nil
+}
+
+func (p *AstPrinter) VisitVariableExpr(expr *VariableExpr) (any, error) {
+	return atom(expr.name.lexeme), nil
+}
+
+// formatLiteral quotes the strings, so they can be told apart from the identifiers
+func formatLiteral(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return fmt.Sprintf("%q", value)
+	}
+
+	return fmt.Sprint(value)
+}
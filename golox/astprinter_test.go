@@ -0,0 +1,87 @@
+package golox
+
+import "testing"
+
+func TestAstPrinterExpr(t *testing.T) {
+	expr := NewBinaryExpr(
+		NewUnaryExpr(NewToken(MINUS, "-", nil, 1), NewLiteralExpr(float64(123))),
+		NewToken(STAR, "*", nil, 1),
+		NewGroupingExpr(NewLiteralExpr(45.5)),
+	)
+
+	expected := "(* (- 123) (group 45.5))"
+
+	if got := NewAstPrinter().PrintExpr(expr); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+}
+
+func TestAstPrinter(t *testing.T) {
+	printerTests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "statements",
+			source:   "var a = \"x\"; if (a and !b) print a; else { a = nil; }",
+			expected: "(var a \"x\")\n(if (and a (! b)) (print a) (block (; (= a nil))))",
+		},
+		{
+			name:     "functions",
+			source:   "fun add(a, b) { return a + b; } fun nothing() { return; }",
+			expected: "(fun add (a b) (return (+ a b)))\n(fun nothing () (return))",
+		},
+		{
+			name:     "classes",
+			source:   "class B < A { init() { this.x = super.y(1); } }",
+			expected: "(class B < A (method init () (; (= (. this x) (call (super y) 1)))))",
+		},
+		{
+			name:     "interpolation",
+			source:   "print \"${a} and ${b}!\";",
+			expected: "(print (+ (+ (+ (+ \"\" a) \" and \") b) \"!\"))",
+		},
+		{
+			name:     "desugared for",
+			source:   "for (var i = 0; i < 3; i = i + 1) print i;",
+			expected: "(block (var i 0) (while (< i 3) (block (print i) (; (= i (+ i 1))))))",
+		},
+	}
+
+	for _, tt := range printerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, err := parseSource(t, tt.source)
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if got := NewAstPrinter().Print(stmts); got != tt.expected {
+				t.Errorf("got\n%s\nexpected\n%s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAstPrinterTree(t *testing.T) {
+	stmts, err := parseSource(t, "var a = 1 + 2;\nprint -a;")
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := `var
+  a
+  +
+    1
+    2
+print
+  -
+    a
+`
+
+	if got := NewAstPrinter().PrintTree(stmts); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+}
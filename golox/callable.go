@@ -0,0 +1,183 @@
+package golox
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoxCallable is implemented by every value that can be called, like functions and classes
+type LoxCallable interface {
+	// Arity is the number of arguments the callable expects
+	Arity() int
+	Call(interpreter *Interpteter, arguments []any) (any, error)
+}
+
+type LoxFunction struct {
+	declaration *FunctionStmt
+	// the environment that is active when the function is declared, not when it's called
+	closure       *Environment
+	isInitializer bool
+}
+
+func NewLoxFunction(declaration *FunctionStmt, closure *Environment, isInitializer bool) LoxFunction {
+	return LoxFunction{declaration, closure, isInitializer}
+}
+
+// bind creates a new environment nested in the method's closure in which "this" is bound to the instance
+func (f LoxFunction) bind(instance *LoxInstance) LoxFunction {
+	environment := NewEnclosedEnvironment(f.closure)
+	environment.define("this", instance)
+
+	return NewLoxFunction(f.declaration, environment, f.isInitializer)
+}
+
+func (f LoxFunction) Arity() int {
+	return len(f.declaration.params)
+}
+
+func (f LoxFunction) Call(interpreter *Interpteter, arguments []any) (any, error) {
+	// each call gets its own environment, otherwise recursion would break
+	environment := NewEnclosedEnvironment(f.closure)
+
+	for i, param := range f.declaration.params {
+		environment.define(param.lexeme, arguments[i])
+	}
+
+	err := interpreter.executeBlock(f.declaration.body, environment)
+
+	if returnValue, ok := err.(*Return); ok {
+		// an empty return inside init() returns this
+		if f.isInitializer {
+			return f.closure.getAt(0, "this"), nil
+		}
+
+		return returnValue.value, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// calling init() directly on an instance also returns this
+	if f.isInitializer {
+		return f.closure.getAt(0, "this"), nil
+	}
+
+	return nil, nil
+}
+
+func (f LoxFunction) String() string {
+	return fmt.Sprintf("<fn %s>", f.declaration.name.lexeme)
+}
+
+// NativeFunction is a function implemented in Go and exposed to the Lox code. It's always used
+// as a pointer, a struct with a func field can't be compared, so == would panic.
+type NativeFunction struct {
+	arity int
+	fn    func(interpreter *Interpteter, arguments []any) (any, error)
+}
+
+func (f *NativeFunction) Arity() int {
+	return f.arity
+}
+
+func (f *NativeFunction) Call(interpreter *Interpteter, arguments []any) (any, error) {
+	return f.fn(interpreter, arguments)
+}
+
+func (f *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// Native is a Go function that the Lox code can call, it's registered with VM.Register
+type Native struct {
+	// Params are the types of the arguments, their number is the arity of the function
+	Params []ValueType
+	// Fn gets the arguments after their types were checked, an error it returns stops
+	// the program with a RuntimeError at the call
+	Fn func(args []Value) (Value, error)
+}
+
+// newNativeFunction wraps the native in a callable that checks the arguments and converts the
+// result with ToValue, the Go integers become int64 when the integers are turned on
+func newNativeFunction(name string, native Native, integers bool) *NativeFunction {
+	return &NativeFunction{
+		arity: len(native.Params),
+		fn: func(_ *Interpteter, arguments []any) (any, error) {
+			args := make([]Value, len(arguments))
+
+			for i, param := range native.Params {
+				value, ok := param.accepts(arguments[i])
+
+				if !ok {
+					return nil, &NativeError{fmt.Sprintf("argument %d of '%s' must be %s, got %s", i+1, name, param, typeName(arguments[i]))}
+				}
+
+				args[i] = value
+			}
+
+			result, err := native.Fn(args)
+
+			if err != nil {
+				return nil, &NativeError{fmt.Sprintf("%s: %v", name, err)}
+			}
+
+			value, err := toValue(result, integers)
+
+			if err != nil {
+				return nil, &NativeError{fmt.Sprintf("%s returned a bad value, %v", name, err)}
+			}
+
+			return value, nil
+		},
+	}
+}
+
+// nativeModule groups the native functions registered with VM.RegisterModule. Unlike the
+// instances, the Lox code can't change its functions.
+type nativeModule struct {
+	name      string
+	functions map[string]*NativeFunction
+}
+
+func (m *nativeModule) get(name Token) (any, error) {
+	if function, ok := m.functions[name.lexeme]; ok {
+		return function, nil
+	}
+
+	return nil, NewRuntimeError(name, fmt.Sprintf("undefined function '%s' in module '%s'", name.lexeme, m.name))
+}
+
+func (m *nativeModule) String() string {
+	return fmt.Sprintf("<module %s>", m.name)
+}
+
+// clock returns the number of seconds since the Unix epoch
+var clock = &NativeFunction{
+	arity: 0,
+	fn: func(*Interpteter, []any) (any, error) {
+		return float64(time.Now().UnixMilli()) / 1000.0, nil
+	},
+}
+
+// newArgs creates the args global, the script arguments are read with args.get(i),
+// which returns nil for a missing argument, and counted with args.length
+func newArgs(args []string) *LoxInstance {
+	instance := NewLoxInstance(NewLoxClass("Args", nil, map[string]LoxFunction{}))
+
+	instance.fields["length"] = float64(len(args))
+	instance.fields["get"] = &NativeFunction{
+		arity: 1,
+		fn: func(_ *Interpteter, arguments []any) (any, error) {
+			index, ok := toInt(arguments[0])
+
+			if !ok || index < 0 || index >= int64(len(args)) {
+				return nil, nil
+			}
+
+			return args[index], nil
+		},
+	}
+
+	return instance
+}
@@ -0,0 +1,85 @@
+package golox
+
+import "fmt"
+
+// LoxClass is callable, calling a class creates a new instance of it
+type LoxClass struct {
+	name       string
+	superclass *LoxClass
+	methods    map[string]LoxFunction
+}
+
+func NewLoxClass(name string, superclass *LoxClass, methods map[string]LoxFunction) *LoxClass {
+	return &LoxClass{name, superclass, methods}
+}
+
+// findMethod walks up the inheritance chain if the method isn't defined in the class itself
+func (c *LoxClass) findMethod(name string) (LoxFunction, bool) {
+	if method, ok := c.methods[name]; ok {
+		return method, true
+	}
+
+	if c.superclass != nil {
+		return c.superclass.findMethod(name)
+	}
+
+	return LoxFunction{}, false
+}
+
+// Arity is the arity of the initializer, or 0 if the class doesn't have one
+func (c *LoxClass) Arity() int {
+	if initializer, ok := c.findMethod("init"); ok {
+		return initializer.Arity()
+	}
+
+	return 0
+}
+
+func (c *LoxClass) Call(interpreter *Interpteter, arguments []any) (any, error) {
+	instance := NewLoxInstance(c)
+
+	if initializer, ok := c.findMethod("init"); ok {
+		_, err := initializer.bind(instance).Call(interpreter, arguments)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return instance, nil
+}
+
+func (c *LoxClass) String() string {
+	return c.name
+}
+
+type LoxInstance struct {
+	class  *LoxClass
+	fields map[string]any
+}
+
+func NewLoxInstance(class *LoxClass) *LoxInstance {
+	return &LoxInstance{class: class, fields: make(map[string]any)}
+}
+
+// get looks for a field first, so fields shadow methods
+func (i *LoxInstance) get(name Token) (any, error) {
+	if value, ok := i.fields[name.lexeme]; ok {
+		return value, nil
+	}
+
+	if method, ok := i.class.findMethod(name.lexeme); ok {
+		return method.bind(i), nil
+	}
+
+	return nil, NewRuntimeError(name, fmt.Sprintf("undefined property '%s'", name.lexeme))
+}
+
+// set freely creates new fields, Lox doesn't declare them in the class
+func (i *LoxInstance) set(name Token, value any) {
+	i.fields[name.lexeme] = value
+}
+
+func (i *LoxInstance) String() string {
+	return fmt.Sprintf("%s instance", i.class.name)
+}
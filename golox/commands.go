@@ -0,0 +1,164 @@
+package golox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type replCommand struct {
+	usage       string
+	description string
+	run         func(l *Lox, argument string) error
+}
+
+// replCommands are the meta-commands of the REPL, they start with a colon, eg. :load main.lox
+var replCommands map[string]replCommand
+
+func init() {
+	// initialized in init, because :help refers to the map itself
+	replCommands = map[string]replCommand{
+		"help":   {":help", "show this list of commands", (*Lox).helpCommand},
+		"load":   {":load <file>", "run a file in the current session", (*Lox).loadCommand},
+		"tokens": {":tokens <source>", "show the tokens of the source", (*Lox).tokensCommand},
+		"ast":    {":ast <source>", "show the syntax tree of the source", (*Lox).astCommand},
+		"env":    {":env", "list the global variables", (*Lox).envCommand},
+		"time":   {":time <source>", "run the source and show how long it took", (*Lox).timeCommand},
+		"reset":  {":reset", "clear all the variables and start a new session", (*Lox).resetCommand},
+	}
+}
+
+// runCommand executes a meta-command line, the errors are reported and don't end the session
+func (l *Lox) runCommand(line string) {
+	name, argument, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	argument = strings.TrimSpace(argument)
+
+	command, ok := replCommands[name]
+
+	if !ok {
+		fmt.Fprintf(l.interpreter.stderr, "unknown command ':%s', type :help for the list of commands\n", name)
+
+		return
+	}
+
+	err := command.run(l, argument)
+
+	if err != nil {
+		l.report(l.file, argument, err)
+	}
+}
+
+func (l *Lox) helpCommand(string) error {
+	names := make([]string, 0, len(replCommands))
+
+	for name := range replCommands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		command := replCommands[name]
+		fmt.Fprintf(l.interpreter.stdout, "%-18s %s\n", command.usage, command.description)
+	}
+
+	return nil
+}
+
+func (l *Lox) loadCommand(file string) error {
+	if file == "" {
+		return fmt.Errorf("usage: %s", replCommands["load"].usage)
+	}
+
+	source, err := os.ReadFile(file)
+
+	if err != nil {
+		return err
+	}
+
+	err = l.run(file, string(source))
+
+	// the diagnostics point to the loaded file, not to the command
+	if err != nil {
+		l.report(file, string(source), err)
+	}
+
+	return nil
+}
+
+func (l *Lox) tokensCommand(source string) error {
+	return printTokens(l.interpreter.stdout, source)
+}
+
+func printTokens(out io.Writer, source string) error {
+	scanner := NewScanner(source)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		fmt.Fprintf(out, "%d:%-4d %-14s %-10s %s\n", token.line, token.column, token.tokenType, token.lexeme, formatLiteral(token.literal))
+	}
+
+	return nil
+}
+
+func (l *Lox) astCommand(source string) error {
+	scanner := NewScanner(source)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		return err
+	}
+
+	parser := NewParser(tokens)
+	parser.repl = true
+
+	stmts, err := parser.parse()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(l.interpreter.stdout, NewAstPrinter().Print(stmts))
+
+	return nil
+}
+
+func (l *Lox) envCommand(string) error {
+	globals := l.interpreter.globals.values
+	names := make([]string, 0, len(globals))
+
+	for name := range globals {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(l.interpreter.stdout, "%s = %s\n", name, formatLiteral(globals[name]))
+	}
+
+	return nil
+}
+
+func (l *Lox) timeCommand(source string) error {
+	start := time.Now()
+
+	err := l.eval(source)
+
+	fmt.Fprintf(l.interpreter.stdout, "elapsed: %v\n", time.Since(start))
+
+	return err
+}
+
+func (l *Lox) resetCommand(string) error {
+	l.interpreter = l.newInterpreter()
+
+	return nil
+}
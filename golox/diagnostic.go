@@ -0,0 +1,208 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type Severity int
+
+const (
+	ERROR_SEVERITY Severity = iota
+	WARNING_SEVERITY
+)
+
+func (s Severity) String() string {
+	if s == WARNING_SEVERITY {
+		return "warning"
+	}
+
+	return "error"
+}
+
+// Diagnostic describes a problem found in the source code. Line and Column are 1-based,
+// Column counts the characters, not the bytes. Start and End are the byte offsets of the
+// problematic part of the source.
+type Diagnostic struct {
+	File     string
+	Line     int
+	Column   int
+	Start    int
+	End      int
+	Severity Severity
+	Message  string
+	// origin is the source of the token the diagnostic was created for
+	origin *origin
+}
+
+// NewDiagnostic creates an error diagnostic that spans the given token
+func NewDiagnostic(token Token, message string) Diagnostic {
+	return Diagnostic{
+		Line:     token.line,
+		Column:   token.column,
+		Start:    token.start,
+		End:      token.end,
+		Severity: ERROR_SEVERITY,
+		Message:  message,
+		origin:   token.origin,
+	}
+}
+
+// String is the compact, single line version of the diagnostic, eg. main.lox:3:7: error: message
+func (d Diagnostic) String() string {
+	location := fmt.Sprintf("%d:%d", d.Line, d.Column)
+
+	if d.File != "" {
+		location = d.File + ":" + location
+	}
+
+	return fmt.Sprintf("%s: %s: %s", location, d.Severity, d.Message)
+}
+
+// Render formats the diagnostic together with the source line it points to:
+//
+//	error: expected expression
+//	 --> main.lox:1:9
+//	  |
+//	1 | var a = ;
+//	  |         ^
+func (d Diagnostic) Render(source string) string {
+	var b strings.Builder
+
+	location := fmt.Sprintf("%d:%d", d.Line, d.Column)
+
+	if d.File != "" {
+		location = d.File + ":" + location
+	}
+
+	lineNumber := strconv.Itoa(d.Line)
+	gutter := strings.Repeat(" ", len(lineNumber))
+
+	fmt.Fprintf(&b, "%s: %s\n", d.Severity, d.Message)
+	fmt.Fprintf(&b, "%s--> %s\n", gutter, location)
+
+	line, ok := sourceLine(source, d.Line)
+
+	if !ok {
+		return b.String()
+	}
+
+	// the column and the underline count the characters, not the bytes
+	chars := []rune(line)
+
+	column := max(d.Column, 1)
+	column = min(column, len(chars)+1)
+
+	length := d.End - d.Start
+
+	if d.Start >= 0 && d.Start <= d.End && d.End <= len(source) {
+		length = utf8.RuneCountInString(source[d.Start:d.End])
+	}
+
+	// the underline never goes past the end of the line, multi-line spans are cut
+	length = min(length, len(chars)-column+1)
+	underlined := chars[column-1 : column-1+max(length, 0)]
+
+	fmt.Fprintf(&b, "%s |\n", gutter)
+	fmt.Fprintf(&b, "%s | %s\n", lineNumber, line)
+	fmt.Fprintf(&b, "%s | %s%s\n", gutter, caretIndent(chars[:column-1]), strings.Repeat("^", max(displayWidth(underlined), 1)))
+
+	return b.String()
+}
+
+// sourceLine returns the n-th line of the source, without the line terminator
+func sourceLine(source string, n int) (string, bool) {
+	lines := strings.Split(source, "\n")
+
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+
+	return strings.TrimRight(lines[n-1], "\r"), true
+}
+
+// caretIndent keeps the tabs from the source line, so the caret lines up with the token
+func caretIndent(prefix []rune) string {
+	var b strings.Builder
+
+	for _, char := range prefix {
+		if char == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteString(strings.Repeat(" ", charWidth(char)))
+		}
+	}
+
+	return b.String()
+}
+
+func displayWidth(chars []rune) int {
+	width := 0
+
+	for _, char := range chars {
+		width += charWidth(char)
+	}
+
+	return width
+}
+
+// charWidth is the number of terminal columns a character takes: the combining marks take
+// none, the East Asian wide characters and the emoji take two
+func charWidth(char rune) int {
+	if unicode.In(char, unicode.Mn, unicode.Me) {
+		return 0
+	}
+
+	for _, wide := range wideChars {
+		if char >= wide[0] && char <= wide[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// wideChars are the most common ranges of the wide characters
+var wideChars = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK radicals and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, CJK symbols
+	{0x3400, 0x4DBF},   // CJK extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE30, 0xFE4F},   // CJK compatibility forms
+	{0xFF00, 0xFF60},   // fullwidth forms
+	{0xFFE0, 0xFFE6},   // fullwidth signs
+	{0x1F300, 0x1F64F}, // symbols, pictographs and emoticons
+	{0x1F900, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x20000, 0x3FFFD}, // CJK extensions B and later
+}
+
+// Diagnostics extracts the diagnostics from an error returned by the scanner, parser, resolver or interpreter
+func Diagnostics(err error) []Diagnostic {
+	var parseErrors ParseErrors
+
+	if errors.As(err, &parseErrors) {
+		diagnostics := make([]Diagnostic, 0, len(parseErrors))
+
+		for _, parseErr := range parseErrors {
+			diagnostics = append(diagnostics, parseErr.Diagnostic())
+		}
+
+		return diagnostics
+	}
+
+	var located interface{ Diagnostic() Diagnostic }
+
+	if errors.As(err, &located) {
+		return []Diagnostic{located.Diagnostic()}
+	}
+
+	return nil
+}
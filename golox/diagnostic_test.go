@@ -0,0 +1,146 @@
+package golox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiagnosticRender(t *testing.T) {
+	source := "var a = 1;\nprint a +\n  \"unterminated;\n"
+
+	scanner := NewScanner(source)
+	_, err := scanner.ScanTokens()
+
+	var scanErr *ScanError
+
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("got %v, expected a scan error", err)
+	}
+
+	diagnostic := scanErr.Diagnostic()
+	diagnostic.File = "main.lox"
+
+	expected := "error: unterminated string\n" +
+		" --> main.lox:3:3\n" +
+		"  |\n" +
+		"3 |   \"unterminated;\n" +
+		"  |   ^^^^^^^^^^^^^^\n"
+
+	if got := diagnostic.Render(source); got != expected {
+		t.Errorf("got\n%s\nexpected\n%s", got, expected)
+	}
+
+	if got := diagnostic.String(); got != "main.lox:3:3: error: unterminated string" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	source := "var name =\n\t\"multi\nline\" + x;"
+
+	scanner := NewScanner(source)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	positions := []struct {
+		lexeme string
+		line   int
+		column int
+		start  int
+		end    int
+	}{
+		{"var", 1, 1, 0, 3},
+		{"name", 1, 5, 4, 8},
+		{"=", 1, 10, 9, 10},
+		{"multi\nline", 2, 2, 12, 24},
+		{"+", 3, 7, 25, 26},
+		{"x", 3, 9, 27, 28},
+		{";", 3, 10, 28, 29},
+		{"", 3, 11, 29, 29},
+	}
+
+	if len(tokens) != len(positions) {
+		t.Fatalf("got %d tokens, expected %d", len(tokens), len(positions))
+	}
+
+	for i, expected := range positions {
+		token := tokens[i]
+
+		if token.lexeme != expected.lexeme || token.line != expected.line || token.column != expected.column ||
+			token.start != expected.start || token.end != expected.end {
+			t.Errorf("got %q at %d:%d [%d, %d), expected %q at %d:%d [%d, %d)",
+				token.lexeme, token.line, token.column, token.start, token.end,
+				expected.lexeme, expected.line, expected.column, expected.start, expected.end)
+		}
+	}
+}
+
+func TestRuntimeErrorDiagnostic(t *testing.T) {
+	_, err := interpretSource(t, "var a = 1;\nprint a + true;")
+
+	diagnostics := Diagnostics(err)
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("got %v, expected a single diagnostic", err)
+	}
+
+	if diagnostics[0].Line != 2 || diagnostics[0].Column != 9 {
+		t.Errorf("got %s, expected a diagnostic at 2:9", diagnostics[0])
+	}
+}
+
+func TestDiagnosticRenderUnicode(t *testing.T) {
+	renderTests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:   "multi-byte characters",
+			source: "var größe = -\"ä\";",
+			expected: "error: operand must be a number\n" +
+				" --> 1:13\n" +
+				"  |\n" +
+				"1 | var größe = -\"ä\";\n" +
+				"  |             ^\n",
+		},
+		{
+			name:   "wide characters",
+			source: "var 名前 = @;",
+			expected: "error: unexpected character '@'\n" +
+				" --> 1:10\n" +
+				"  |\n" +
+				"1 | var 名前 = @;\n" +
+				"  |            ^\n",
+		},
+	}
+
+	for _, tt := range renderTests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.source)
+			tokens, err := scanner.ScanTokens()
+
+			// the runtime error is faked from the minus token, only the position matters
+			if err == nil {
+				for _, token := range tokens {
+					if token.tokenType == MINUS {
+						err = NewRuntimeError(token, "operand must be a number")
+					}
+				}
+			}
+
+			diagnostics := Diagnostics(err)
+
+			if len(diagnostics) != 1 {
+				t.Fatalf("got %v, expected one diagnostic", err)
+			}
+
+			if got := diagnostics[0].Render(tt.source); got != tt.expected {
+				t.Errorf("got\n%s\nexpected\n%s", got, tt.expected)
+			}
+		})
+	}
+}
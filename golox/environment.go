@@ -0,0 +1,72 @@
+package golox
+
+import "fmt"
+
+// Environment stores the bindings that associate variables to values
+type Environment struct {
+	enclosing *Environment
+	values    map[string]any
+}
+
+// NewEnvironment creates the global scope environment
+func NewEnvironment() *Environment {
+	return &Environment{values: make(map[string]any)}
+}
+
+// NewEnclosedEnvironment creates a local scope nested inside the outer one
+func NewEnclosedEnvironment(enclosing *Environment) *Environment {
+	return &Environment{enclosing: enclosing, values: make(map[string]any)}
+}
+
+// define binds a new name to a value. Redefining an existing variable is allowed.
+func (e *Environment) define(name string, value any) {
+	e.values[name] = value
+}
+
+func (e *Environment) get(name Token) (any, error) {
+	if value, ok := e.values[name.lexeme]; ok {
+		return value, nil
+	}
+
+	// walk the chain of the enclosing environments
+	if e.enclosing != nil {
+		return e.enclosing.get(name)
+	}
+
+	return nil, NewRuntimeError(name, fmt.Sprintf("undefined variable '%s'", name.lexeme))
+}
+
+// assign is not allowed to create a new variable
+func (e *Environment) assign(name Token, value any) error {
+	if _, ok := e.values[name.lexeme]; ok {
+		e.values[name.lexeme] = value
+
+		return nil
+	}
+
+	if e.enclosing != nil {
+		return e.enclosing.assign(name, value)
+	}
+
+	return NewRuntimeError(name, fmt.Sprintf("undefined variable '%s'", name.lexeme))
+}
+
+// ancestor walks a fixed number of hops up the parent chain
+func (e *Environment) ancestor(distance int) *Environment {
+	environment := e
+
+	for i := 0; i < distance; i++ {
+		environment = environment.enclosing
+	}
+
+	return environment
+}
+
+// getAt doesn't need to check if the variable exists, the resolver already found it
+func (e *Environment) getAt(distance int, name string) any {
+	return e.ancestor(distance).values[name]
+}
+
+func (e *Environment) assignAt(distance int, name Token, value any) {
+	e.ancestor(distance).values[name.lexeme] = value
+}
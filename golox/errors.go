@@ -1,14 +1,119 @@
 package golox
 
+import (
+	"fmt"
+	"strings"
+)
+
+// ScanError is reported by the scanner for characters that don't form a valid token
+type ScanError struct {
+	diagnostic Diagnostic
+	// incomplete is set when the source ended in the middle of a token, eg. an unterminated string
+	incomplete bool
+}
+
+func (e ScanError) Error() string {
+	return fmt.Sprintf("error in line %d: %s", e.diagnostic.Line, e.diagnostic.Message)
+}
+
+func (e ScanError) Diagnostic() Diagnostic {
+	return e.diagnostic
+}
+
+func NewScanError(diagnostic Diagnostic) error {
+	return &ScanError{diagnostic: diagnostic}
+}
+
 type RuntimeError struct {
 	message string
 	token   Token
 }
 
 func (e RuntimeError) Error() string {
-	return e.message
+	return fmt.Sprintf("error in line %d at '%s': %s", e.token.line, e.token.lexeme, e.message)
+}
+
+func (e RuntimeError) Diagnostic() Diagnostic {
+	return NewDiagnostic(e.token, e.message)
 }
 
 func NewRuntimeError(token Token, message string) error {
 	return &RuntimeError{message, token}
 }
+
+// StepLimitError stops a program that executed more statements than allowed, eg. an endless loop
+type StepLimitError struct {
+	limit int
+}
+
+func (e StepLimitError) Error() string {
+	return fmt.Sprintf("the program exceeded the limit of %d steps", e.limit)
+}
+
+func NewStepLimitError(limit int) error {
+	return &StepLimitError{limit}
+}
+
+// NativeError is the failure of a native function, the interpreter turns it into a RuntimeError
+// at the call. It's only returned as it is when the function is called by VM.Call, which has
+// no call in the source to point to.
+type NativeError struct {
+	message string
+}
+
+func (e *NativeError) Error() string {
+	return e.message
+}
+
+// Message describes the failure, eg. a wrong argument or the error the Go function returned
+func (e *NativeError) Message() string {
+	return e.message
+}
+
+// Return isn't really an error, it's used to unwind the call stack from a return
+// statement all the way up to the function call
+type Return struct {
+	value any
+}
+
+func (r Return) Error() string {
+	return "return outside of a function"
+}
+
+func NewReturn(value any) error {
+	return &Return{value}
+}
+
+type ParseError struct {
+	message string
+	token   Token
+}
+
+func (e ParseError) Error() string {
+	if e.token.tokenType == EOF {
+		return fmt.Sprintf("error in line %d at end: %s", e.token.line, e.message)
+	}
+
+	return fmt.Sprintf("error in line %d at '%s': %s", e.token.line, e.token.lexeme, e.message)
+}
+
+func (e ParseError) Diagnostic() Diagnostic {
+	return NewDiagnostic(e.token, e.message)
+}
+
+func NewParseError(token Token, message string) error {
+	return &ParseError{message, token}
+}
+
+// ParseErrors holds all the errors the parser found, in the order they appear in the source
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "\n")
+}
@@ -1,10 +1,19 @@
 package golox
 
 type IExprVisitor interface {
-	VisitLiteralExpr(expr LiteralExpr) (any, error)
-	VisitBinaryExpr(expr BinaryExpr) (any, error)
-	VisitGroupingExpr(expr GroupingExpr) (any, error)
-	VisitUnaryExpr(expr UnaryExpr) (any, error)
+	VisitLiteralExpr(expr *LiteralExpr) (any, error)
+	VisitBinaryExpr(expr *BinaryExpr) (any, error)
+	VisitGroupingExpr(expr *GroupingExpr) (any, error)
+	VisitUnaryExpr(expr *UnaryExpr) (any, error)
+	VisitVariableExpr(expr *VariableExpr) (any, error)
+	VisitAssignExpr(expr *AssignExpr) (any, error)
+	VisitLogicalExpr(expr *LogicalExpr) (any, error)
+	VisitCallExpr(expr *CallExpr) (any, error)
+	VisitGetExpr(expr *GetExpr) (any, error)
+	VisitSetExpr(expr *SetExpr) (any, error)
+	VisitThisExpr(expr *ThisExpr) (any, error)
+	VisitSuperExpr(expr *SuperExpr) (any, error)
+	VisitInterpolationExpr(expr *InterpolationExpr) (any, error)
 }
 
 type IExpr interface {
@@ -29,13 +38,15 @@ operator       → "==" | "!=" | "<" | "<=" | ">" | ">=" | "+"  | "-"  | "*" | "
 
 type LiteralExpr struct {
 	value any
+	// token is the source token of numbers and strings, it keeps their original spelling
+	token *Token
 }
 
-func NewLiteralExpr(v any) LiteralExpr {
-	return LiteralExpr{v}
+func NewLiteralExpr(v any) *LiteralExpr {
+	return &LiteralExpr{value: v}
 }
 
-func (expr LiteralExpr) Accept(v IExprVisitor) (any, error) {
+func (expr *LiteralExpr) Accept(v IExprVisitor) (any, error) {
 	return v.VisitLiteralExpr(expr)
 }
 
@@ -45,11 +56,11 @@ type BinaryExpr struct {
 	right    IExpr
 }
 
-func NewBinaryExpr(l IExpr, o Token, r IExpr) BinaryExpr {
-	return BinaryExpr{l, o, r}
+func NewBinaryExpr(l IExpr, o Token, r IExpr) *BinaryExpr {
+	return &BinaryExpr{l, o, r}
 }
 
-func (expr BinaryExpr) Accept(v IExprVisitor) (any, error) {
+func (expr *BinaryExpr) Accept(v IExprVisitor) (any, error) {
 	return v.VisitBinaryExpr(expr)
 }
 
@@ -57,11 +68,11 @@ type GroupingExpr struct {
 	expression IExpr
 }
 
-func NewGroupingExpr(e IExpr) GroupingExpr {
-	return GroupingExpr{e}
+func NewGroupingExpr(e IExpr) *GroupingExpr {
+	return &GroupingExpr{e}
 }
 
-func (expr GroupingExpr) Accept(v IExprVisitor) (any, error) {
+func (expr *GroupingExpr) Accept(v IExprVisitor) (any, error) {
 	return v.VisitGroupingExpr(expr)
 }
 
@@ -70,10 +81,154 @@ type UnaryExpr struct {
 	right    IExpr
 }
 
-func NewUnaryExpr(o Token, r IExpr) UnaryExpr {
-	return UnaryExpr{o, r}
+func NewUnaryExpr(o Token, r IExpr) *UnaryExpr {
+	return &UnaryExpr{o, r}
 }
 
-func (expr UnaryExpr) Accept(v IExprVisitor) (any, error) {
+func (expr *UnaryExpr) Accept(v IExprVisitor) (any, error) {
 	return v.VisitUnaryExpr(expr)
 }
+
+// local is the number of scopes between an expression and the variable it uses, it's set by
+// the resolver for the local variables only, the others are globals. It's a part of the syntax
+// tree, so it's freed together with the code.
+type local struct {
+	depth    int
+	resolved bool
+}
+
+func (l *local) resolve(depth int) {
+	l.depth = depth
+	l.resolved = true
+}
+
+type VariableExpr struct {
+	name Token
+	local
+}
+
+func NewVariableExpr(name Token) *VariableExpr {
+	return &VariableExpr{name: name}
+}
+
+func (expr *VariableExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitVariableExpr(expr)
+}
+
+type AssignExpr struct {
+	name  Token
+	value IExpr
+	local
+}
+
+func NewAssignExpr(name Token, value IExpr) *AssignExpr {
+	return &AssignExpr{name: name, value: value}
+}
+
+func (expr *AssignExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitAssignExpr(expr)
+}
+
+// LogicalExpr is separate from BinaryExpr because the operators short-circuit
+type LogicalExpr struct {
+	left     IExpr
+	operator Token
+	right    IExpr
+}
+
+func NewLogicalExpr(l IExpr, o Token, r IExpr) *LogicalExpr {
+	return &LogicalExpr{l, o, r}
+}
+
+func (expr *LogicalExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitLogicalExpr(expr)
+}
+
+type CallExpr struct {
+	callee IExpr
+	// the closing parenthesis token is used to report runtime errors of the call
+	paren     Token
+	arguments []IExpr
+}
+
+func NewCallExpr(callee IExpr, paren Token, arguments []IExpr) *CallExpr {
+	return &CallExpr{callee, paren, arguments}
+}
+
+func (expr *CallExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitCallExpr(expr)
+}
+
+// GetExpr is a property access on an instance, eg. object.name
+type GetExpr struct {
+	object IExpr
+	name   Token
+}
+
+func NewGetExpr(object IExpr, name Token) *GetExpr {
+	return &GetExpr{object, name}
+}
+
+func (expr *GetExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitGetExpr(expr)
+}
+
+type SetExpr struct {
+	object IExpr
+	name   Token
+	value  IExpr
+}
+
+func NewSetExpr(object IExpr, name Token, value IExpr) *SetExpr {
+	return &SetExpr{object, name, value}
+}
+
+func (expr *SetExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitSetExpr(expr)
+}
+
+type ThisExpr struct {
+	keyword Token
+	local
+}
+
+func NewThisExpr(keyword Token) *ThisExpr {
+	return &ThisExpr{keyword: keyword}
+}
+
+func (expr *ThisExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitThisExpr(expr)
+}
+
+// SuperExpr is always followed by a method access, eg. super.method
+type SuperExpr struct {
+	keyword Token
+	method  Token
+	local
+}
+
+func NewSuperExpr(keyword Token, method Token) *SuperExpr {
+	return &SuperExpr{keyword: keyword, method: method}
+}
+
+func (expr *SuperExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitSuperExpr(expr)
+}
+
+// InterpolationExpr is a string with embedded expressions, eg. "Hello ${name}!". The parts
+// alternate between the string literals and the expressions, the first and the last part
+// are always literals, even if they are empty. Like the ForStmt, the parts are only kept for
+// the formatter, which prints the string as it was written. The interpreter and the resolver
+// only see the desugared concatenation, eg. "Hello " + name + "!".
+type InterpolationExpr struct {
+	parts     []IExpr
+	desugared IExpr
+}
+
+func NewInterpolationExpr(parts []IExpr, desugared IExpr) *InterpolationExpr {
+	return &InterpolationExpr{parts, desugared}
+}
+
+func (expr *InterpolationExpr) Accept(v IExprVisitor) (any, error) {
+	return v.VisitInterpolationExpr(expr)
+}
@@ -0,0 +1,467 @@
+package golox
+
+import (
+	"strings"
+)
+
+// layout is what the formatter needs to know about the source, besides the syntax tree:
+// the comments and the positions of the statements
+type layout struct {
+	comments []Token
+	// the first and the last token of every statement in a list, eg. a block or a class body
+	starts map[IStmt]Token
+	ends   map[IStmt]Token
+	// the braces of the blocks, functions and classes
+	openingBraces map[IStmt]Token
+	closingBraces map[IStmt]Token
+}
+
+func newLayout(comments []Token) *layout {
+	return &layout{
+		comments:      comments,
+		starts:        map[IStmt]Token{},
+		ends:          map[IStmt]Token{},
+		openingBraces: map[IStmt]Token{},
+		closingBraces: map[IStmt]Token{},
+	}
+}
+
+// verbatim finds the statements with a comment the formatter has no place for, eg. inside an
+// expression, a parameter list or before the body of an if without braces. The formatter only
+// places the comments between the statements of a body, so these statements are kept as they are.
+func (l *layout) verbatim() map[IStmt]bool {
+	verbatim := map[IStmt]bool{}
+
+	for _, comment := range l.comments {
+		// the innermost statement around the comment
+		var stmt IStmt
+		stmtStart := -1
+
+		for s, start := range l.starts {
+			if start.start <= comment.start && comment.start < l.ends[s].end && start.start > stmtStart {
+				stmt, stmtStart = s, start.start
+			}
+		}
+
+		if stmt == nil {
+			continue
+		}
+
+		// the innermost body around the comment, if it's inside the statement the comment
+		// is between the statements of that body
+		bodyStart := -1
+
+		for s, open := range l.openingBraces {
+			if open.start < comment.start && comment.start < l.closingBraces[s].start && open.start > bodyStart {
+				bodyStart = open.start
+			}
+		}
+
+		if bodyStart < stmtStart {
+			verbatim[stmt] = true
+		}
+	}
+
+	return verbatim
+}
+
+// Formatter prints the syntax tree back as source code, with two spaces of indentation,
+// one statement per line and the braces on the same line as the statement they belong to.
+// The comments are kept, a comment is either on its own line before a statement or at the
+// end of the line after it. A statement with a comment anywhere else, eg. inside an expression,
+// is printed as it was written. A single blank line between statements is kept as well.
+type Formatter struct {
+	source string
+	layout *layout
+	// verbatim are the statements printed as they were written, because of their comments
+	verbatim map[IStmt]bool
+	out      strings.Builder
+	indent   int
+	// atLineStart delays the indentation until something is written, so the blank lines stay empty
+	atLineStart bool
+	// nextComment is the index of the first comment that wasn't printed yet
+	nextComment int
+}
+
+// Format parses the source and returns it in the canonical form. The source must not have
+// syntax errors. Formatting an already formatted source doesn't change it.
+func Format(source string) (string, error) {
+	scanner := NewScanner(source)
+	scanner.keepComments = true
+
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		return "", err
+	}
+
+	code := make([]Token, 0, len(tokens))
+	comments := []Token{}
+
+	for _, token := range tokens {
+		if token.tokenType == COMMENT {
+			comments = append(comments, token)
+		} else {
+			code = append(code, token)
+		}
+	}
+
+	parser := NewParser(code)
+	parser.layout = newLayout(comments)
+
+	stmts, err := parser.parse()
+
+	if err != nil {
+		return "", err
+	}
+
+	f := &Formatter{source: source, layout: parser.layout, verbatim: parser.layout.verbatim(), atLineStart: true}
+
+	lastLine := f.statements(stmts, len(source), func(stmt IStmt) { stmt.Accept(f) })
+	f.commentsBefore(len(source), &lastLine)
+
+	return f.out.String(), nil
+}
+
+func (f *Formatter) write(s string) {
+	if f.atLineStart {
+		f.out.WriteString(strings.Repeat("  ", f.indent))
+		f.atLineStart = false
+	}
+
+	f.out.WriteString(s)
+}
+
+func (f *Formatter) newLine() {
+	f.out.WriteString("\n")
+	f.atLineStart = true
+}
+
+// statements prints each statement on its own line, together with its comments, the body ends
+// at the offset. It returns the source line of the last thing it printed.
+func (f *Formatter) statements(stmts []IStmt, bodyEnd int, print func(stmt IStmt)) int {
+	lastLine := 0
+
+	for _, stmt := range stmts {
+		start := f.layout.starts[stmt]
+		end := f.layout.ends[stmt]
+
+		f.commentsBefore(start.start, &lastLine)
+
+		if lastLine > 0 && start.line > lastLine+1 {
+			f.newLine()
+		}
+
+		if f.verbatim[stmt] {
+			f.write(f.source[start.start:end.end])
+			f.skipCommentsBefore(end.end)
+		} else {
+			print(stmt)
+		}
+
+		lastLine = end.line
+
+		// a comment on the same line stays at the end of it, unless it's after the closing brace
+		// of the body, then it belongs to the statement the body is a part of
+		if f.nextComment < len(f.layout.comments) {
+			comment := f.layout.comments[f.nextComment]
+
+			if comment.line == end.line && comment.start >= end.end && comment.start < bodyEnd {
+				f.write(" " + comment.lexeme)
+				f.nextComment++
+				lastLine = commentEndLine(comment)
+			}
+		}
+
+		f.newLine()
+	}
+
+	return lastLine
+}
+
+// commentsBefore prints the remaining comments that start before the offset, each on its own line
+func (f *Formatter) commentsBefore(offset int, lastLine *int) {
+	for f.nextComment < len(f.layout.comments) {
+		comment := f.layout.comments[f.nextComment]
+
+		if comment.start >= offset {
+			return
+		}
+
+		if *lastLine > 0 && comment.line > *lastLine+1 {
+			f.newLine()
+		}
+
+		f.write(comment.lexeme)
+		f.newLine()
+
+		*lastLine = commentEndLine(comment)
+		f.nextComment++
+	}
+}
+
+// skipCommentsBefore marks the comments that start before the offset as printed
+func (f *Formatter) skipCommentsBefore(offset int) {
+	for f.nextComment < len(f.layout.comments) && f.layout.comments[f.nextComment].start < offset {
+		f.nextComment++
+	}
+}
+
+func commentEndLine(comment Token) int {
+	return comment.line + strings.Count(comment.lexeme, "\n")
+}
+
+// block prints the statements between braces, the comments before the closing brace stay inside
+func (f *Formatter) block(stmts []IStmt, closingBrace Token, print func(stmt IStmt)) {
+	f.write("{")
+
+	hasComments := f.nextComment < len(f.layout.comments) && f.layout.comments[f.nextComment].start < closingBrace.start
+
+	if len(stmts) == 0 && !hasComments {
+		f.write("}")
+
+		return
+	}
+
+	f.newLine()
+	f.indent++
+
+	lastLine := f.statements(stmts, closingBrace.start, print)
+	f.commentsBefore(closingBrace.start, &lastLine)
+
+	f.indent--
+	f.write("}")
+}
+
+// body prints the body of an if, while or for statement on the same line as the statement
+func (f *Formatter) body(stmt IStmt) {
+	f.write(" ")
+
+	if block, ok := stmt.(*BlockStmt); ok {
+		f.block(block.statements, f.layout.closingBraces[block], f.statement)
+
+		return
+	}
+
+	stmt.Accept(f)
+}
+
+func (f *Formatter) statement(stmt IStmt) {
+	stmt.Accept(f)
+}
+
+func (f *Formatter) expr(expr IExpr) string {
+	value, _ := expr.Accept(f)
+
+	return value.(string)
+}
+
+func (f *Formatter) function(stmt *FunctionStmt) {
+	params := make([]string, 0, len(stmt.params))
+
+	for _, param := range stmt.params {
+		params = append(params, param.lexeme)
+	}
+
+	f.write(stmt.name.lexeme + "(" + strings.Join(params, ", ") + ") ")
+	f.block(stmt.body, f.layout.closingBraces[stmt], f.statement)
+}
+
+// VisitBlockStmt implements IStmtVisitor.
+func (f *Formatter) VisitBlockStmt(stmt *BlockStmt) error {
+	f.block(stmt.statements, f.layout.closingBraces[stmt], f.statement)
+
+	return nil
+}
+
+// VisitClassStmt implements IStmtVisitor.
+func (f *Formatter) VisitClassStmt(stmt *ClassStmt) error {
+	f.write("class " + stmt.name.lexeme + " ")
+
+	if stmt.superclass != nil {
+		f.write("< " + stmt.superclass.name.lexeme + " ")
+	}
+
+	methods := make([]IStmt, 0, len(stmt.methods))
+
+	for _, method := range stmt.methods {
+		methods = append(methods, method)
+	}
+
+	f.block(methods, f.layout.closingBraces[stmt], func(method IStmt) {
+		f.function(method.(*FunctionStmt))
+	})
+
+	return nil
+}
+
+// VisitExpressionStmt implements IStmtVisitor.
+func (f *Formatter) VisitExpressionStmt(stmt *ExpressionStmt) error {
+	f.write(f.expr(stmt.expr) + ";")
+
+	return nil
+}
+
+// VisitForStmt implements IStmtVisitor.
+func (f *Formatter) VisitForStmt(stmt *ForStmt) error {
+	f.write("for (")
+
+	if stmt.initializer != nil {
+		stmt.initializer.Accept(f)
+	} else {
+		f.write(";")
+	}
+
+	if stmt.condition != nil {
+		f.write(" " + f.expr(stmt.condition))
+	}
+
+	f.write(";")
+
+	if stmt.increment != nil {
+		f.write(" " + f.expr(stmt.increment))
+	}
+
+	f.write(")")
+	f.body(stmt.body)
+
+	return nil
+}
+
+// VisitFunctionStmt implements IStmtVisitor.
+func (f *Formatter) VisitFunctionStmt(stmt *FunctionStmt) error {
+	f.write("fun ")
+	f.function(stmt)
+
+	return nil
+}
+
+// VisitIfStmt implements IStmtVisitor.
+func (f *Formatter) VisitIfStmt(stmt *IfStmt) error {
+	f.write("if (" + f.expr(stmt.condition) + ")")
+	f.body(stmt.thenBranch)
+
+	if stmt.elseBranch == nil {
+		return nil
+	}
+
+	f.write(" else")
+
+	// else if chains stay flat
+	if elseIf, ok := stmt.elseBranch.(*IfStmt); ok {
+		f.write(" ")
+
+		return elseIf.Accept(f)
+	}
+
+	f.body(stmt.elseBranch)
+
+	return nil
+}
+
+// VisitPrintStmt implements IStmtVisitor.
+func (f *Formatter) VisitPrintStmt(stmt *PrintStmt) error {
+	f.write("print " + f.expr(stmt.expr) + ";")
+
+	return nil
+}
+
+// VisitReturnStmt implements IStmtVisitor.
+func (f *Formatter) VisitReturnStmt(stmt *ReturnStmt) error {
+	if stmt.value == nil {
+		f.write("return;")
+	} else {
+		f.write("return " + f.expr(stmt.value) + ";")
+	}
+
+	return nil
+}
+
+// VisitVarStmt implements IStmtVisitor.
+func (f *Formatter) VisitVarStmt(stmt *VarStmt) error {
+	if stmt.initializer == nil {
+		f.write("var " + stmt.name.lexeme + ";")
+	} else {
+		f.write("var " + stmt.name.lexeme + " = " + f.expr(stmt.initializer) + ";")
+	}
+
+	return nil
+}
+
+// VisitWhileStmt implements IStmtVisitor.
+func (f *Formatter) VisitWhileStmt(stmt *WhileStmt) error {
+	f.write("while (" + f.expr(stmt.condition) + ")")
+	f.body(stmt.body)
+
+	return nil
+}
+
+func (f *Formatter) VisitAssignExpr(expr *AssignExpr) (any, error) {
+	return expr.name.lexeme + " = " + f.expr(expr.value), nil
+}
+
+func (f *Formatter) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
+	return f.expr(expr.left) + " " + expr.operator.lexeme + " " + f.expr(expr.right), nil
+}
+
+func (f *Formatter) VisitCallExpr(expr *CallExpr) (any, error) {
+	arguments := make([]string, 0, len(expr.arguments))
+
+	for _, argument := range expr.arguments {
+		arguments = append(arguments, f.expr(argument))
+	}
+
+	return f.expr(expr.callee) + "(" + strings.Join(arguments, ", ") + ")", nil
+}
+
+func (f *Formatter) VisitGetExpr(expr *GetExpr) (any, error) {
+	return f.expr(expr.object) + "." + expr.name.lexeme, nil
+}
+
+func (f *Formatter) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
+	return "(" + f.expr(expr.expression) + ")", nil
+}
+
+func (f *Formatter) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
+	// numbers and strings are printed as they were written, eg. 1.50 stays 1.50
+	if expr.token != nil {
+		return f.source[expr.token.start:expr.token.end], nil
+	}
+
+	return formatLiteral(expr.value), nil
+}
+
+// VisitInterpolationExpr keeps the string parts as they were written, eg. "a ${ b }" becomes "a ${b}"
+func (f *Formatter) VisitInterpolationExpr(expr *InterpolationExpr) (any, error) {
+	var b strings.Builder
+
+	for _, part := range expr.parts {
+		b.WriteString(f.expr(part))
+	}
+
+	return b.String(), nil
+}
+
+func (f *Formatter) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
+	return f.expr(expr.left) + " " + expr.operator.lexeme + " " + f.expr(expr.right), nil
+}
+
+func (f *Formatter) VisitSetExpr(expr *SetExpr) (any, error) {
+	return f.expr(expr.object) + "." + expr.name.lexeme + " = " + f.expr(expr.value), nil
+}
+
+func (f *Formatter) VisitSuperExpr(expr *SuperExpr) (any, error) {
+	return "super." + expr.method.lexeme, nil
+}
+
+func (f *Formatter) VisitThisExpr(expr *ThisExpr) (any, error) {
+	return "this", nil
+}
+
+func (f *Formatter) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
+	return expr.operator.lexeme + f.expr(expr.right), nil
+}
+
+func (f *Formatter) VisitVariableExpr(expr *VariableExpr) (any, error) {
+	return expr.name.lexeme, nil
+}
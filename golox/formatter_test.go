@@ -0,0 +1,140 @@
+package golox
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	formatTests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "spacing",
+			source:   "var   a=1.50;print -a*(2+a);f(a,b).c=!d;",
+			expected: "var a = 1.50;\nprint -a * (2 + a);\nf(a, b).c = !d;\n",
+		},
+		{
+			name:     "blocks",
+			source:   "fun f(a,b){if(a){return;}else if(b)print b; else{return a;}}",
+			expected: "fun f(a, b) {\n  if (a) {\n    return;\n  } else if (b) print b; else {\n    return a;\n  }\n}\n",
+		},
+		{
+			name:     "loops",
+			source:   "for(var i=0;i<3;i=i+1)print i; for(;;){} while(a)\n{a=a-1;}",
+			expected: "for (var i = 0; i < 3; i = i + 1) print i;\nfor (;;) {}\nwhile (a) {\n  a = a - 1;\n}\n",
+		},
+		{
+			name:     "classes",
+			source:   "class B<A{init(x){this.x=super.init(x);}get(){return this.x;}}",
+			expected: "class B < A {\n  init(x) {\n    this.x = super.init(x);\n  }\n  get() {\n    return this.x;\n  }\n}\n",
+		},
+		{
+			name:     "strings",
+			source:   "print \"a\\tb ${  x+1 } \\${y} ${ \"${z}\" }\";",
+			expected: "print \"a\\tb ${x + 1} \\${y} ${\"${z}\"}\";\n",
+		},
+		{
+			name:     "comments",
+			source:   "// top\nvar a; // trailing\n{ // after brace\n  a = 1;\n  // before brace\n}\n// end",
+			expected: "// top\nvar a; // trailing\n{\n  // after brace\n  a = 1;\n  // before brace\n}\n// end\n",
+		},
+		{
+			name:     "block comments",
+			source:   "/* license\n * header\n */\nvar a; /* why */\n{ /* a /* nested */ comment */ }",
+			expected: "/* license\n * header\n */\nvar a; /* why */\n{\n  /* a /* nested */ comment */\n}\n",
+		},
+		{
+			name:     "blank lines",
+			source:   "var a;\n\n\n\nvar b;\nvar c;\n\n// d\nvar d;",
+			expected: "var a;\n\nvar b;\nvar c;\n\n// d\nvar d;\n",
+		},
+		{
+			name:     "comment inside an expression",
+			source:   "var x = 1 + // c\n  2;\nprint   x;",
+			expected: "var x = 1 + // c\n  2;\nprint x;\n",
+		},
+		{
+			name:     "comment before a body without braces",
+			source:   "{\nif (x) // why\n  print x;\nwhile(x)/* loop */x=x-1;\n}",
+			expected: "{\n  if (x) // why\n  print x;\n  while(x)/* loop */x=x-1;\n}\n",
+		},
+		{
+			name:     "comment inside a parameter list",
+			source:   "fun f(a, // first\n  b) {\n  return a;\n}\nfun g( ){return;}",
+			expected: "fun f(a, // first\n  b) {\n  return a;\n}\nfun g() {\n  return;\n}\n",
+		},
+		{
+			name:     "only the innermost statement is kept",
+			source:   "class A {\n m() {\n  print f(1, /* one */ 2);\n  print   3;\n }\n}",
+			expected: "class A {\n  m() {\n    print f(1, /* one */ 2);\n    print 3;\n  }\n}\n",
+		},
+		{
+			name:     "comment after the closing brace of an else",
+			source:   "if (a) {\n  print 0;\n} else {\n  print 1; } // after\nprint 2;",
+			expected: "if (a) {\n  print 0;\n} else {\n  print 1;\n} // after\nprint 2;\n",
+		},
+		{
+			name:     "comment after the closing brace of a method",
+			source:   "class A {\n  n() { return; } // trailing n\n}",
+			expected: "class A {\n  n() {\n    return;\n  } // trailing n\n}\n",
+		},
+	}
+
+	for _, tt := range formatTests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.source)
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("got\n%s\nexpected\n%s", got, tt.expected)
+			}
+
+			again, err := Format(got)
+
+			if err != nil || again != got {
+				t.Errorf("formatting is not idempotent, got\n%s\nexpected\n%s", again, got)
+			}
+		})
+	}
+}
+
+func TestFormatSyntaxError(t *testing.T) {
+	_, err := Format("var a = ;")
+
+	if len(Diagnostics(err)) != 1 {
+		t.Errorf("expected a syntax error, got %v", err)
+	}
+}
+
+// the examples are real programs, formatting them twice must give the same result
+func TestFormatExamples(t *testing.T) {
+	files, _ := filepath.Glob("../examples/*.lox")
+
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Format(string(source))
+
+		// some examples are single expressions for the REPL
+		if err != nil {
+			continue
+		}
+
+		again, err := Format(got)
+
+		if err != nil || again != got {
+			t.Errorf("formatting %s is not idempotent, got\n%s\nexpected\n%s", file, again, got)
+		}
+	}
+}
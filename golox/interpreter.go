@@ -1,15 +1,52 @@
 package golox
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxCallDepth limits the nested calls, a runaway recursion would crash the Go program otherwise
+const maxCallDepth = 10000
 
 // the interpreter struct needs to implement IExprVisitor and IStmtVisitor interfaces
-type Interpteter struct{}
+type Interpteter struct {
+	// globals always points to the outermost environment, while environment changes with the scope
+	globals     *Environment
+	environment *Environment
+	// trace receives every statement before it's executed, nil turns the tracing off
+	trace io.Writer
+	// maxSteps limits the number of statements a program can execute, 0 means no limit
+	maxSteps int
+	steps    int
+	// ctx stops the program when it's cancelled, it's only set while a VM runs the code
+	ctx context.Context
+	// depth is the number of calls in progress
+	depth int
+	// the streams of the program, print writes to stdout, they are the process streams by default
+	stdout io.Writer
+	stderr io.Writer
+	stdin  io.Reader
+}
+
+func NewInterpreter() *Interpteter {
+	globals := NewEnvironment()
+	globals.define("clock", clock)
 
-func NewInterpreter() Interpteter {
-	return Interpteter{}
+	return &Interpteter{
+		globals:     globals,
+		environment: globals,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		stdin:       os.Stdin,
+	}
 }
 
-func (i Interpteter) interpret(stmts []IStmt) error {
+func (i *Interpteter) interpret(stmts []IStmt) error {
+	// the limit applies to each program, eg. to each line of the REPL
+	i.steps = 0
+
 	for _, stmt := range stmts {
 		err := i.execute(stmt)
 		if err != nil {
@@ -20,12 +57,72 @@ func (i Interpteter) interpret(stmts []IStmt) error {
 	return nil
 }
 
+// interpretExpression is interpret for a program that is a single expression, eg. a line of
+// the REPL, it returns the value of the expression
+func (i *Interpteter) interpretExpression(stmt *ExpressionStmt) (any, error) {
+	i.steps = 0
+
+	if err := i.step(stmt); err != nil {
+		return nil, err
+	}
+
+	return i.evaluate(stmt.expr)
+}
+
 // the statement analogue to the evaluate()
-func (i Interpteter) execute(stmt IStmt) error {
+func (i *Interpteter) execute(stmt IStmt) error {
+	if err := i.step(stmt); err != nil {
+		return err
+	}
+
 	return stmt.Accept(i)
 }
 
-func (i Interpteter) VisitBinaryExpr(expr BinaryExpr) (any, error) {
+// step counts and traces the statement before it's executed, it also stops a cancelled program
+func (i *Interpteter) step(stmt IStmt) error {
+	if i.maxSteps > 0 {
+		i.steps++
+
+		if i.steps > i.maxSteps {
+			return NewStepLimitError(i.maxSteps)
+		}
+	}
+
+	if i.ctx != nil {
+		if err := i.ctx.Err(); err != nil {
+			return err
+		}
+	}
+
+	if i.trace != nil {
+		fmt.Fprintf(i.trace, "trace: %s\n", NewAstPrinter().Print([]IStmt{stmt}))
+	}
+
+	return nil
+}
+
+// executeBlock runs the statements in the given environment and restores the
+// previous one afterwards, even if one of the statements fails
+func (i *Interpteter) executeBlock(stmts []IStmt, environment *Environment) error {
+	previous := i.environment
+	defer func() {
+		i.environment = previous
+	}()
+
+	i.environment = environment
+
+	for _, stmt := range stmts {
+		err := i.execute(stmt)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *Interpteter) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
 	left, err := i.evaluate(expr.left)
 
 	if err != nil {
@@ -38,66 +135,72 @@ func (i Interpteter) VisitBinaryExpr(expr BinaryExpr) (any, error) {
 		return nil, err
 	}
 
-	// check the operand types for all operations except PLUS
-	err = checkNumberOperands(expr.operator, left, right)
+	// check the operand types for all operations except PLUS and the equality operators
+	switch expr.operator.tokenType {
+	case PLUS, EQUAL_EQUAL, BANG_EQUAL:
+	default:
+		err = checkNumberOperands(expr.operator, left, right)
 
-	if expr.operator.tokenType != PLUS && err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	switch expr.operator.tokenType {
-	case MINUS:
-		return left.(float64) - right.(float64), nil
-	case SLASH:
-		return left.(float64) / right.(float64), nil
-	case STAR:
-		return left.(float64) * right.(float64), nil
+	case MINUS, SLASH, STAR, PERCENT:
+		return arithmetic(expr.operator, left, right)
 	case PLUS:
-		// The + operator can also be used to concatenate two strings.
-		if left, ok := left.(float64); ok {
-			if right, ok := right.(float64); ok {
-				return left + right, nil
-			}
-		}
-
-		if left, ok := left.(string); ok {
-			if right, ok := right.(string); ok {
-				return left + right, nil
-			}
-		}
-
-		return nil, fmt.Errorf("operands must be two numbers or two strings")
-	case GREATER:
-		return left.(float64) > right.(float64), nil
-	case GREATER_EQUAL:
-		return left.(float64) >= right.(float64), nil
-	case LESS:
-		return left.(float64) < right.(float64), nil
-	case LESS_EQUAL:
-		return left.(float64) <= right.(float64), nil
-	case BANG_EQUAL:
+		// The + operator can also be used to concatenate strings, when one of the operands
+		// is a string the other one is converted to a string, eg. "a" + 1 is "a1".
+		if isNumber(left) && isNumber(right) {
+			return arithmetic(expr.operator, left, right)
+		}
+
+		_, leftIsString := left.(string)
+		_, rightIsString := right.(string)
+
+		if !leftIsString && !rightIsString {
+			return nil, NewRuntimeError(expr.operator, "operands must be two numbers or at least one string")
+		}
+
+		a, err := i.stringify(left)
+
+		if err != nil {
+			return nil, err
+		}
+
+		b, err := i.stringify(right)
 
 		if err != nil {
 			return nil, err
 		}
 
+		return a + b, nil
+	case GREATER, GREATER_EQUAL, LESS, LESS_EQUAL:
+		return compare(expr.operator, left, right), nil
+	case BANG_EQUAL:
 		return !isEqual(left, right), nil
 	case EQUAL_EQUAL:
 		return isEqual(left, right), nil
 	}
 
-	return nil, fmt.Errorf("unsupported expression")
+	return nil, NewRuntimeError(expr.operator, "unsupported expression")
 }
 
-func (i Interpteter) VisitGroupingExpr(expr GroupingExpr) (any, error) {
+func (i *Interpteter) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
 	return i.evaluate(expr.expression)
 }
 
-func (Interpteter) VisitLiteralExpr(expr LiteralExpr) (any, error) {
+func (*Interpteter) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
 	return expr.value, nil
 }
 
-func (i Interpteter) VisitUnaryExpr(expr UnaryExpr) (any, error) {
+// VisitInterpolationExpr evaluates the desugared concatenation of the parts
+func (i *Interpteter) VisitInterpolationExpr(expr *InterpolationExpr) (any, error) {
+	return i.evaluate(expr.desugared)
+}
+
+func (i *Interpteter) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
 	right, err := i.evaluate(expr.right)
 
 	if err != nil {
@@ -112,38 +215,353 @@ func (i Interpteter) VisitUnaryExpr(expr UnaryExpr) (any, error) {
 			return nil, err
 		}
 
-		return -right.(float64), nil
+		return negate(right), nil
 	case BANG:
 		return !isTruthy(right), nil
 	}
 
-	return nil, fmt.Errorf("unsupported expression")
+	return nil, NewRuntimeError(expr.operator, "unsupported expression")
 }
 
-func (i Interpteter) evaluate(expr IExpr) (any, error) {
+func (i *Interpteter) evaluate(expr IExpr) (any, error) {
 	return expr.Accept(i)
 }
 
 // VisitExpressionStmt implements IStmtVisitor.
-func (i Interpteter) VisitExpressionStmt(stmt ExpressionStmt) error {
+func (i *Interpteter) VisitExpressionStmt(stmt *ExpressionStmt) error {
 	_, err := i.evaluate(stmt.expr)
 
 	return err
 }
 
 // VisitPrintStmt implements IStmtVisitor.
-func (i Interpteter) VisitPrintStmt(stmt PrintStmt) error {
+func (i *Interpteter) VisitPrintStmt(stmt *PrintStmt) error {
 	val, err := i.evaluate(stmt.expr)
 
 	if err != nil {
 		return err
 	}
 
-	fmt.Println(val)
+	text, err := i.stringify(val)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(i.stdout, text)
+
+	return err
+}
+
+// VisitVarStmt implements IStmtVisitor.
+func (i *Interpteter) VisitVarStmt(stmt *VarStmt) error {
+	var value any
+
+	// variables without an initializer are set to nil
+	if stmt.initializer != nil {
+		var err error
+		value, err = i.evaluate(stmt.initializer)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	i.environment.define(stmt.name.lexeme, value)
 
 	return nil
 }
 
+// VisitBlockStmt implements IStmtVisitor.
+func (i *Interpteter) VisitBlockStmt(stmt *BlockStmt) error {
+	return i.executeBlock(stmt.statements, NewEnclosedEnvironment(i.environment))
+}
+
+// VisitIfStmt implements IStmtVisitor.
+func (i *Interpteter) VisitIfStmt(stmt *IfStmt) error {
+	condition, err := i.evaluate(stmt.condition)
+
+	if err != nil {
+		return err
+	}
+
+	if isTruthy(condition) {
+		return i.execute(stmt.thenBranch)
+	}
+
+	if stmt.elseBranch != nil {
+		return i.execute(stmt.elseBranch)
+	}
+
+	return nil
+}
+
+// VisitWhileStmt implements IStmtVisitor.
+func (i *Interpteter) VisitWhileStmt(stmt *WhileStmt) error {
+	for {
+		condition, err := i.evaluate(stmt.condition)
+
+		if err != nil {
+			return err
+		}
+
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		err = i.execute(stmt.body)
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// VisitForStmt implements IStmtVisitor.
+func (i *Interpteter) VisitForStmt(stmt *ForStmt) error {
+	return i.execute(stmt.desugared)
+}
+
+func (i *Interpteter) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
+	left, err := i.evaluate(expr.left)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// short-circuit and return the left operand itself instead of true/false
+	if expr.operator.tokenType == OR {
+		if isTruthy(left) {
+			return left, nil
+		}
+	} else {
+		if !isTruthy(left) {
+			return left, nil
+		}
+	}
+
+	return i.evaluate(expr.right)
+}
+
+// VisitFunctionStmt implements IStmtVisitor.
+func (i *Interpteter) VisitFunctionStmt(stmt *FunctionStmt) error {
+	function := NewLoxFunction(stmt, i.environment, false)
+	i.environment.define(stmt.name.lexeme, function)
+
+	return nil
+}
+
+// VisitClassStmt implements IStmtVisitor.
+func (i *Interpteter) VisitClassStmt(stmt *ClassStmt) error {
+	var superclass *LoxClass
+
+	if stmt.superclass != nil {
+		value, err := i.evaluate(stmt.superclass)
+
+		if err != nil {
+			return err
+		}
+
+		class, ok := value.(*LoxClass)
+
+		if !ok {
+			return NewRuntimeError(stmt.superclass.name, "superclass must be a class")
+		}
+
+		superclass = class
+	}
+
+	i.environment.define(stmt.name.lexeme, nil)
+
+	// the methods of a subclass are closed over an environment where "super" is bound to the superclass
+	if superclass != nil {
+		i.environment = NewEnclosedEnvironment(i.environment)
+		i.environment.define("super", superclass)
+	}
+
+	methods := make(map[string]LoxFunction, len(stmt.methods))
+
+	for _, method := range stmt.methods {
+		methods[method.name.lexeme] = NewLoxFunction(method, i.environment, method.name.lexeme == "init")
+	}
+
+	class := NewLoxClass(stmt.name.lexeme, superclass, methods)
+
+	if superclass != nil {
+		i.environment = i.environment.enclosing
+	}
+
+	// the two-stage binding allows references to the class inside its own methods
+	return i.environment.assign(stmt.name, class)
+}
+
+// VisitReturnStmt implements IStmtVisitor.
+func (i *Interpteter) VisitReturnStmt(stmt *ReturnStmt) error {
+	var value any
+
+	if stmt.value != nil {
+		var err error
+		value, err = i.evaluate(stmt.value)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return NewReturn(value)
+}
+
+func (i *Interpteter) VisitCallExpr(expr *CallExpr) (any, error) {
+	callee, err := i.evaluate(expr.callee)
+
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := make([]any, 0, len(expr.arguments))
+
+	for _, argument := range expr.arguments {
+		value, err := i.evaluate(argument)
+
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, value)
+	}
+
+	function, ok := callee.(LoxCallable)
+
+	if !ok {
+		return nil, NewRuntimeError(expr.paren, "can only call functions and classes")
+	}
+
+	if len(arguments) != function.Arity() {
+		return nil, NewRuntimeError(expr.paren, fmt.Sprintf("expected %d arguments but got %d", function.Arity(), len(arguments)))
+	}
+
+	result, err := i.call(function, arguments, expr.paren)
+
+	// the native functions don't know where they were called from
+	if nativeErr, ok := err.(*NativeError); ok {
+		return nil, NewRuntimeError(expr.paren, nativeErr.message)
+	}
+
+	return result, err
+}
+
+// call reports a stack overflow at the token when there are too many nested calls
+func (i *Interpteter) call(function LoxCallable, arguments []any, token Token) (any, error) {
+	if i.depth >= maxCallDepth {
+		return nil, NewRuntimeError(token, "stack overflow")
+	}
+
+	i.depth++
+	defer func() {
+		i.depth--
+	}()
+
+	return function.Call(i, arguments)
+}
+
+func (i *Interpteter) VisitGetExpr(expr *GetExpr) (any, error) {
+	object, err := i.evaluate(expr.object)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if instance, ok := object.(*LoxInstance); ok {
+		return instance.get(expr.name)
+	}
+
+	if module, ok := object.(*nativeModule); ok {
+		return module.get(expr.name)
+	}
+
+	return nil, NewRuntimeError(expr.name, "only instances have properties")
+}
+
+func (i *Interpteter) VisitSetExpr(expr *SetExpr) (any, error) {
+	object, err := i.evaluate(expr.object)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if module, ok := object.(*nativeModule); ok {
+		return nil, NewRuntimeError(expr.name, fmt.Sprintf("can't change the functions of module '%s'", module.name))
+	}
+
+	instance, ok := object.(*LoxInstance)
+
+	if !ok {
+		return nil, NewRuntimeError(expr.name, "only instances have fields")
+	}
+
+	value, err := i.evaluate(expr.value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	instance.set(expr.name, value)
+
+	return value, nil
+}
+
+func (i *Interpteter) VisitThisExpr(expr *ThisExpr) (any, error) {
+	return i.lookUpVariable(expr.keyword, expr.local)
+}
+
+func (i *Interpteter) VisitSuperExpr(expr *SuperExpr) (any, error) {
+	superclass := i.environment.getAt(expr.depth, "super").(*LoxClass)
+
+	// "this" is always bound in the environment right inside the one where "super" is bound
+	object := i.environment.getAt(expr.depth-1, "this").(*LoxInstance)
+
+	method, ok := superclass.findMethod(expr.method.lexeme)
+
+	if !ok {
+		return nil, NewRuntimeError(expr.method, fmt.Sprintf("undefined property '%s'", expr.method.lexeme))
+	}
+
+	return method.bind(object), nil
+}
+
+func (i *Interpteter) VisitVariableExpr(expr *VariableExpr) (any, error) {
+	return i.lookUpVariable(expr.name, expr.local)
+}
+
+func (i *Interpteter) lookUpVariable(name Token, local local) (any, error) {
+	if local.resolved {
+		return i.environment.getAt(local.depth, name.lexeme), nil
+	}
+
+	return i.globals.get(name)
+}
+
+func (i *Interpteter) VisitAssignExpr(expr *AssignExpr) (any, error) {
+	value, err := i.evaluate(expr.value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.resolved {
+		i.environment.assignAt(expr.depth, expr.name, value)
+	} else {
+		err = i.globals.assign(expr.name, value)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// assignment is an expression, so it returns the assigned value
+	return value, nil
+}
+
 func isTruthy(value any) bool {
 	// false and nil are falsey, and everything else is truthy
 	if value == nil {
@@ -167,11 +585,64 @@ func isEqual(a any, b any) bool {
 		return false
 	}
 
+	// an integer equals the float with the same value, eg. 1 == 1.0
+	if isNumber(a) && isNumber(b) {
+		if _, ok := a.(float64); ok {
+			return a == toFloat(b)
+		}
+
+		if _, ok := b.(float64); ok {
+			return toFloat(a) == b
+		}
+	}
+
 	return a == b
 }
 
+// stringify converts a value to the text that print shows, a class can override it for its
+// instances with a toString method
+func (i *Interpteter) stringify(value any) (string, error) {
+	instance, ok := value.(*LoxInstance)
+
+	if !ok {
+		return stringify(value), nil
+	}
+
+	method, ok := instance.class.findMethod("toString")
+
+	if !ok {
+		return stringify(value), nil
+	}
+
+	result, err := i.call(method.bind(instance), nil, method.declaration.name)
+
+	if err != nil {
+		return "", err
+	}
+
+	text, ok := result.(string)
+
+	if !ok {
+		return "", NewRuntimeError(method.declaration.name, "toString must return a string")
+	}
+
+	return text, nil
+}
+
+// stringify converts a value to its Lox text, eg. nil instead of Go's <nil> and 3 instead of 3.0
+func stringify(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case float64, int64:
+		return formatNumber(value)
+	}
+
+	return fmt.Sprint(value)
+}
+
 func checkNumberOperand(operator Token, operand any) error {
-	if _, ok := operand.(float64); !ok {
+	if !isNumber(operand) {
 		return NewRuntimeError(operator, fmt.Sprintf("%v operand must be a number", operand))
 	}
 
@@ -179,11 +650,11 @@ func checkNumberOperand(operator Token, operand any) error {
 }
 
 func checkNumberOperands(operator Token, left any, right any) error {
-	if _, ok := left.(float64); !ok {
+	if !isNumber(left) {
 		return NewRuntimeError(operator, fmt.Sprintf("%v operand must be a number", left))
 	}
 
-	if _, ok := right.(float64); !ok {
+	if !isNumber(right) {
 		return NewRuntimeError(operator, fmt.Sprintf("%v operand must be a number", right))
 	}
 
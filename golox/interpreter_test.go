@@ -1,6 +1,11 @@
 package golox
 
-import "testing"
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
 
 type expressionTest struct {
 	name       string
@@ -84,6 +89,38 @@ func TestBinaryExpressionEval(t *testing.T) {
 	runExprTests(t, binaryExpressionsTests)
 }
 
+func TestLogicalExpressionEval(t *testing.T) {
+	logicalExpressionsTests := []expressionTest{
+		{
+			name:       "nil or \"yes\"",
+			expression: NewLogicalExpr(NewLiteralExpr(nil), NewToken(OR, "or", "or", 0), NewLiteralExpr("yes")),
+			expected:   "yes",
+		},
+		{
+			name:       "\"hi\" or 2",
+			expression: NewLogicalExpr(NewLiteralExpr("hi"), NewToken(OR, "or", "or", 0), NewLiteralExpr(float64(2))),
+			expected:   "hi",
+		},
+		{
+			name:       "false and 1",
+			expression: NewLogicalExpr(NewLiteralExpr(false), NewToken(AND, "and", "and", 0), NewLiteralExpr(float64(1))),
+			expected:   false,
+		},
+		{
+			name: "false and undefined",
+			expression: NewLogicalExpr(
+				NewLiteralExpr(false),
+				NewToken(AND, "and", "and", 0),
+				// never evaluated, otherwise it'd be a runtime error
+				NewVariableExpr(NewToken(IDENTIFIER, "undefined", "undefined", 0)),
+			),
+			expected: false,
+		},
+	}
+
+	runExprTests(t, logicalExpressionsTests)
+}
+
 func runExprTests(t *testing.T, expressions []expressionTest) {
 	t.Helper()
 
@@ -99,3 +136,346 @@ func runExprTests(t *testing.T, expressions []expressionTest) {
 		})
 	}
 }
+
+func TestVariables(t *testing.T) {
+	loxInterpreter := NewInterpreter()
+	name := NewToken(IDENTIFIER, "a", "a", 1)
+
+	err := loxInterpreter.execute(NewVarStmt(name, NewLiteralExpr(float64(1))))
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got, _ := loxInterpreter.evaluate(NewAssignExpr(name, NewBinaryExpr(
+		NewVariableExpr(name),
+		NewToken(PLUS, "+", "+", 1),
+		NewLiteralExpr(float64(2)),
+	)))
+
+	if got != 3.0 {
+		t.Errorf("got %v, expected %v", got, 3.0)
+	}
+
+	undefined := NewToken(IDENTIFIER, "b", "b", 2)
+	_, err = loxInterpreter.evaluate(NewVariableExpr(undefined))
+
+	runtimeErr, ok := err.(*RuntimeError)
+
+	if !ok {
+		t.Fatalf("got %v, expected a runtime error", err)
+	}
+
+	if runtimeErr.token != undefined {
+		t.Errorf("got token %v, expected %v", runtimeErr.token, undefined)
+	}
+}
+
+func TestBlockScope(t *testing.T) {
+	loxInterpreter := NewInterpreter()
+	globals := loxInterpreter.environment
+	a := NewToken(IDENTIFIER, "a", "a", 1)
+
+	loxInterpreter.execute(NewVarStmt(a, NewLiteralExpr("global")))
+
+	// { var a = "local"; a = "shadowed"; b; }
+	block := NewBlockStmt([]IStmt{
+		NewVarStmt(a, NewLiteralExpr("local")),
+		NewExpressionStmt(NewAssignExpr(a, NewLiteralExpr("shadowed"))),
+		NewExpressionStmt(NewVariableExpr(NewToken(IDENTIFIER, "b", "b", 1))),
+	})
+
+	err := NewResolver().resolve([]IStmt{block})
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	err = loxInterpreter.execute(block)
+
+	if _, ok := err.(*RuntimeError); !ok {
+		t.Fatalf("got %v, expected a runtime error", err)
+	}
+
+	if loxInterpreter.environment != globals {
+		t.Errorf("the global environment was not restored after the block")
+	}
+
+	got, _ := loxInterpreter.evaluate(NewVariableExpr(a))
+
+	if got != "global" {
+		t.Errorf("got %v, expected %v", got, "global")
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	loxInterpreter := NewInterpreter()
+	paren := NewToken(RIGHT_PAREN, ")", nil, 3)
+
+	callTests := []struct {
+		name string
+		expr IExpr
+	}{
+		{
+			name: "\"not a function\"()",
+			expr: NewCallExpr(NewLiteralExpr("not a function"), paren, []IExpr{}),
+		},
+		{
+			name: "clock(1)",
+			expr: NewCallExpr(NewVariableExpr(NewToken(IDENTIFIER, "clock", "clock", 3)), paren, []IExpr{NewLiteralExpr(float64(1))}),
+		},
+	}
+
+	for _, tt := range callTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loxInterpreter.evaluate(tt.expr)
+
+			runtimeErr, ok := err.(*RuntimeError)
+
+			if !ok {
+				t.Fatalf("got %v, expected a runtime error", err)
+			}
+
+			if runtimeErr.token != paren {
+				t.Errorf("got token %v, expected %v", runtimeErr.token, paren)
+			}
+		})
+	}
+}
+
+// interpretSource runs the whole pipeline and returns the interpreter, so the globals can be inspected
+func interpretSource(t *testing.T, source string) (*Interpteter, error) {
+	t.Helper()
+
+	scanner := NewScanner(source)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		t.Fatalf("unexpected scan error %v", err)
+	}
+
+	parser := NewParser(tokens)
+	stmts, err := parser.parse()
+
+	if err != nil {
+		t.Fatalf("unexpected parse error %v", err)
+	}
+
+	loxInterpreter := NewInterpreter()
+
+	err = NewResolver().resolve(stmts)
+
+	if err != nil {
+		t.Fatalf("unexpected resolve error %v", err)
+	}
+
+	return loxInterpreter, loxInterpreter.interpret(stmts)
+}
+
+func TestClasses(t *testing.T) {
+	classTests := []struct {
+		name     string
+		source   string
+		expected any
+	}{
+		{
+			name:     "initializer and fields",
+			source:   "class Point { init(x, y) { this.x = x; this.y = y; } }\nvar result = Point(1, 2).y;",
+			expected: 2.0,
+		},
+		{
+			name:     "bound method",
+			source:   "class A { name() { return this.n; } }\nvar a = A();\na.n = \"a\";\nvar m = a.name;\na.n = \"b\";\nvar result = m();",
+			expected: "b",
+		},
+		{
+			name:     "init returns this",
+			source:   "class A { init() { return; } }\nvar a = A();\nvar result = a.init() == a;",
+			expected: true,
+		},
+		{
+			name:     "inherited method",
+			source:   "class A { name() { return \"A\"; } }\nclass B < A {}\nvar result = B().name();",
+			expected: "A",
+		},
+		{
+			name: "super call",
+			source: "class A { name() { return \"A\"; } }\n" +
+				"class B < A { name() { return \"B\" + super.name(); } }\n" +
+				"class C < B {}\n" +
+				"var result = C().name();",
+			expected: "BA",
+		},
+	}
+
+	for _, tt := range classTests {
+		t.Run(tt.name, func(t *testing.T) {
+			loxInterpreter, err := interpretSource(t, tt.source)
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			got, _ := loxInterpreter.globals.get(NewToken(IDENTIFIER, "result", "result", 0))
+
+			if got != tt.expected {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInheritFromNonClass(t *testing.T) {
+	_, err := interpretSource(t, "var NotAClass = \"so not a class\";\nclass Oops < NotAClass {}")
+
+	runtimeErr, ok := err.(*RuntimeError)
+
+	if !ok {
+		t.Fatalf("got %v, expected a runtime error", err)
+	}
+
+	if runtimeErr.token.lexeme != "NotAClass" || runtimeErr.token.line != 2 {
+		t.Errorf("got token %v, expected the superclass name in line 2", runtimeErr.token)
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	interpolationTests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "variable", source: "var name = \"Lox\";\nvar result = \"Hello ${name}!\";", expected: "Hello Lox!"},
+		{name: "values", source: "var result = \"${1 + 2} ${2.5} ${true} ${nil}\";", expected: "3 2.5 true nil"},
+		{name: "empty parts", source: "var a = 1;\nvar result = \"${a}${a}\";", expected: "11"},
+		{name: "nested", source: "var a = \"x\";\nvar result = \"[${\"<${a}>\"}]\";", expected: "[<x>]"},
+		{name: "escaped", source: "var result = \"\\${a} \\\"q\\\"\\t\\u{e9}\";", expected: "${a} \"q\"\t\u00e9"},
+	}
+
+	for _, tt := range interpolationTests {
+		t.Run(tt.name, func(t *testing.T) {
+			loxInterpreter, err := interpretSource(t, tt.source)
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			got, _ := loxInterpreter.globals.get(NewToken(IDENTIFIER, "result", "result", 0))
+
+			if got != tt.expected {
+				t.Errorf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIntegers(t *testing.T) {
+	integerTests := []struct {
+		name     string
+		source   string
+		expected any
+	}{
+		{name: "integer division", source: "var result = 7 / 2;", expected: int64(3)},
+		{name: "remainder", source: "var result = -7 % 3;", expected: int64(-1)},
+		{name: "float remainder", source: "var result = 7.5 % 2;", expected: 1.5},
+		{name: "mixed", source: "var result = 7 / 2.0;", expected: 3.5},
+		{name: "negate", source: "var result = -(1 + 2);", expected: int64(-3)},
+		{name: "compare", source: "var result = 2 < 2.5;", expected: true},
+		{name: "equal", source: "var result = 1 == 1.0;", expected: true},
+		{name: "precision", source: "var result = 9007199254740993 - 9007199254740992;", expected: int64(1)},
+		{name: "interpolation", source: "var result = \"${0xFF} ${1.5}\";", expected: "255 1.5"},
+	}
+
+	for _, tt := range integerTests {
+		t.Run(tt.name, func(t *testing.T) {
+			lox := New(bufio.NewReader(strings.NewReader("")))
+			lox.SetOptions(Options{Integers: true})
+
+			if err := lox.run("", tt.source); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			got, _ := lox.interpreter.globals.get(NewToken(IDENTIFIER, "result", "result", 0))
+
+			if got != tt.expected {
+				t.Errorf("got %v (%T), expected %v (%T)", got, got, tt.expected, tt.expected)
+			}
+		})
+	}
+
+	lox := New(bufio.NewReader(strings.NewReader("")))
+	lox.SetOptions(Options{Integers: true})
+
+	if err := lox.run("", "print 1 / 0;"); !errors.As(err, new(*RuntimeError)) {
+		t.Errorf("got %v, expected a division by zero error", err)
+	}
+}
+
+func TestStringify(t *testing.T) {
+	stringifyTests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "nil and booleans", source: "var result = \"\" + nil + true + false;", expected: "niltruefalse"},
+		{name: "whole float", source: "var result = \"\" + 3.0 + \" \" + 2.5;", expected: "3 2.5"},
+		{name: "infinity", source: "var result = \"${1 / 0} ${-1 / 0}\";", expected: "Infinity -Infinity"},
+		{name: "number first", source: "var result = 1 + \"a\";", expected: "1a"},
+		{name: "function", source: "fun f() {}\nvar result = \"\" + f + clock;", expected: "<fn f><native fn>"},
+		{name: "class and instance", source: "class A {}\nvar result = \"${A} ${A()}\";", expected: "A A instance"},
+		{
+			name:     "toString",
+			source:   "class P { init(x) { this.x = x; } toString() { return \"P(${this.x})\"; } }\nvar result = \"${P(1)} \" + P(2);",
+			expected: "P(1) P(2)",
+		},
+		{
+			name:     "inherited toString",
+			source:   "class A { toString() { return \"an A\"; } }\nclass B < A {}\nvar result = \"\" + B();",
+			expected: "an A",
+		},
+	}
+
+	for _, tt := range stringifyTests {
+		t.Run(tt.name, func(t *testing.T) {
+			loxInterpreter, err := interpretSource(t, tt.source)
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			got, _ := loxInterpreter.globals.get(NewToken(IDENTIFIER, "result", "result", 0))
+
+			if got != tt.expected {
+				t.Errorf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+
+	_, err := interpretSource(t, "class A { toString() { return 1; } }\nprint A();")
+
+	if runtimeErr, ok := err.(*RuntimeError); !ok || runtimeErr.token.lexeme != "toString" {
+		t.Errorf("got %v, expected an error at toString", err)
+	}
+
+	_, err = interpretSource(t, "print true + nil;")
+
+	if !errors.As(err, new(*RuntimeError)) {
+		t.Errorf("got %v, expected a runtime error", err)
+	}
+}
+
+func TestNativeFunctionEquality(t *testing.T) {
+	loxInterpreter, err := interpretSource(t, "var same = clock == clock;\nvar different = clock != clock;\nvar other = clock == 1;")
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := map[string]any{"same": true, "different": false, "other": false}
+
+	for name, value := range expected {
+		if got := loxInterpreter.globals.values[name]; got != value {
+			t.Errorf("got %s = %v, expected %v", name, got, value)
+		}
+	}
+}
@@ -0,0 +1,524 @@
+package golox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// errInterrupted is returned when the user presses Ctrl-C, the current input should be discarded
+var errInterrupted = errors.New("interrupted")
+
+const (
+	historyFileName = ".golox_history"
+	maxHistory      = 1000
+)
+
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyBackspace = 8
+	keyTab       = 9
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// the keys that are sent as escape sequences, they're mapped outside the rune range
+const (
+	keyUp rune = -(iota + 1)
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDeleteForward
+	keyUnknown
+)
+
+// lineEditor reads lines from a terminal in raw mode. It supports cursor movement,
+// history (persisted in a file), reverse search with Ctrl-R and tab completion.
+type lineEditor struct {
+	in  *bufio.Reader
+	out io.Writer
+	// raw switches the terminal to raw mode, it returns the function that restores the terminal
+	raw         func() (func() error, error)
+	history     []string
+	historyFile string
+	// complete returns the candidates that start with the given word
+	complete func(word string) []string
+}
+
+// newTerminalEditor returns nil if the input is not a terminal
+func newTerminalEditor(in *os.File, out io.Writer, complete func(word string) []string) *lineEditor {
+	if !isTerminal(in.Fd()) {
+		return nil
+	}
+
+	editor := &lineEditor{
+		in:       bufio.NewReader(in),
+		out:      out,
+		raw:      func() (func() error, error) { return makeRaw(in.Fd()) },
+		complete: complete,
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		editor.historyFile = filepath.Join(home, historyFileName)
+		editor.loadHistory()
+	}
+
+	return editor
+}
+
+// editState is the line that is being edited
+type editState struct {
+	prompt string
+	buffer []rune
+	// cursor is the index in the buffer, not the screen column
+	cursor int
+	// historyIndex is len(history) while the user edits a new line
+	historyIndex int
+	// pending keeps the new line while the user browses the history
+	pending []rune
+}
+
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	if e.raw != nil {
+		restore, err := e.raw()
+
+		if err != nil {
+			return "", err
+		}
+
+		defer restore()
+	}
+
+	state := &editState{prompt: prompt, historyIndex: len(e.history)}
+	e.refresh(state)
+
+	for {
+		key, err := e.readKey()
+
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyEnter, '\n':
+			line := string(state.buffer)
+
+			fmt.Fprint(e.out, "\r\n")
+			e.addHistory(line)
+
+			return line + "\n", nil
+		case keyCtrlC:
+			fmt.Fprint(e.out, "^C\r\n")
+
+			return "", errInterrupted
+		case keyCtrlD:
+			// Ctrl-D on an empty line ends the session, otherwise it deletes like the delete key
+			if len(state.buffer) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+
+				return "", io.EOF
+			}
+
+			e.deleteForward(state)
+		case keyDeleteForward:
+			e.deleteForward(state)
+		case keyBackspace, keyDelete:
+			if state.cursor > 0 {
+				state.buffer = append(state.buffer[:state.cursor-1], state.buffer[state.cursor:]...)
+				state.cursor--
+			}
+		case keyLeft, keyCtrlB:
+			if state.cursor > 0 {
+				state.cursor--
+			}
+		case keyRight, keyCtrlF:
+			if state.cursor < len(state.buffer) {
+				state.cursor++
+			}
+		case keyHome, keyCtrlA:
+			state.cursor = 0
+		case keyEnd, keyCtrlE:
+			state.cursor = len(state.buffer)
+		case keyUp, keyCtrlP:
+			e.historyMove(state, -1)
+		case keyDown, keyCtrlN:
+			e.historyMove(state, 1)
+		case keyCtrlK:
+			state.buffer = state.buffer[:state.cursor]
+		case keyCtrlU:
+			state.buffer = state.buffer[state.cursor:]
+			state.cursor = 0
+		case keyCtrlW:
+			// the spaces right before the cursor are deleted together with the word
+			end := state.cursor
+
+			for end > 0 && state.buffer[end-1] == ' ' {
+				end--
+			}
+
+			start := wordStart(state.buffer, end, func(r rune) bool { return r != ' ' })
+			state.buffer = append(state.buffer[:start], state.buffer[state.cursor:]...)
+			state.cursor = start
+		case keyCtrlL:
+			fmt.Fprint(e.out, "\x1b[H\x1b[2J")
+		case keyTab:
+			e.completeWord(state)
+		case keyCtrlR:
+			accepted, err := e.reverseSearch(state)
+
+			if err != nil {
+				return "", err
+			}
+
+			if accepted {
+				line := string(state.buffer)
+
+				fmt.Fprint(e.out, "\r\n")
+				e.addHistory(line)
+
+				return line + "\n", nil
+			}
+		default:
+			if key >= ' ' {
+				e.insert(state, key)
+			}
+		}
+
+		e.refresh(state)
+	}
+}
+
+// readKey reads a single key press, the escape sequences are translated to the key constants
+func (e *lineEditor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+
+	if err != nil || r != keyEscape {
+		return r, err
+	}
+
+	next, _, err := e.in.ReadRune()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if next != '[' && next != 'O' {
+		return keyUnknown, nil
+	}
+
+	// the parameters of sequences like ESC [ 3 ~ come before the final character
+	params := ""
+
+	for {
+		final, _, err := e.in.ReadRune()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if final >= '0' && final <= '9' || final == ';' {
+			params += string(final)
+			continue
+		}
+
+		switch {
+		case final == 'A':
+			return keyUp, nil
+		case final == 'B':
+			return keyDown, nil
+		case final == 'C':
+			return keyRight, nil
+		case final == 'D':
+			return keyLeft, nil
+		case final == 'H', final == '~' && (params == "1" || params == "7"):
+			return keyHome, nil
+		case final == 'F', final == '~' && (params == "4" || params == "8"):
+			return keyEnd, nil
+		case final == '~' && params == "3":
+			return keyDeleteForward, nil
+		}
+
+		return keyUnknown, nil
+	}
+}
+
+func (e *lineEditor) insert(state *editState, runes ...rune) {
+	buffer := make([]rune, 0, len(state.buffer)+len(runes))
+	buffer = append(buffer, state.buffer[:state.cursor]...)
+	buffer = append(buffer, runes...)
+	buffer = append(buffer, state.buffer[state.cursor:]...)
+
+	state.buffer = buffer
+	state.cursor += len(runes)
+}
+
+func (e *lineEditor) deleteForward(state *editState) {
+	if state.cursor < len(state.buffer) {
+		state.buffer = append(state.buffer[:state.cursor], state.buffer[state.cursor+1:]...)
+	}
+}
+
+// refresh redraws the whole line and moves the cursor to its position
+func (e *lineEditor) refresh(state *editState) {
+	column := len([]rune(state.prompt)) + state.cursor
+
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K\r", state.prompt, string(state.buffer))
+
+	if column > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dC", column)
+	}
+}
+
+// historyMove replaces the buffer with an older (-1) or a newer (1) history entry
+func (e *lineEditor) historyMove(state *editState, direction int) {
+	index := state.historyIndex + direction
+
+	if index < 0 || index > len(e.history) {
+		return
+	}
+
+	if state.historyIndex == len(e.history) {
+		state.pending = state.buffer
+	}
+
+	state.historyIndex = index
+
+	if index == len(e.history) {
+		state.buffer = state.pending
+	} else {
+		state.buffer = []rune(e.history[index])
+	}
+
+	state.cursor = len(state.buffer)
+}
+
+// completeWord completes the identifier before the cursor. With multiple candidates the
+// common prefix is completed, and if there is nothing to add the candidates are listed.
+func (e *lineEditor) completeWord(state *editState) {
+	if e.complete == nil {
+		return
+	}
+
+	start := wordStart(state.buffer, state.cursor, isIdentifierRune)
+	word := string(state.buffer[start:state.cursor])
+
+	if word == "" {
+		return
+	}
+
+	candidates := e.complete(word)
+
+	if len(candidates) == 0 {
+		return
+	}
+
+	prefix := commonPrefix(candidates)
+
+	if len(prefix) > len(word) {
+		e.insert(state, []rune(prefix[len(word):])...)
+		return
+	}
+
+	if len(candidates) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(candidates, "  "))
+	}
+}
+
+// reverseSearch is the Ctrl-R incremental search through the history, from the newest entry.
+// Enter runs the found line, Ctrl-G or Ctrl-C cancel the search and any other key keeps
+// the found line for editing.
+func (e *lineEditor) reverseSearch(state *editState) (bool, error) {
+	original := state.buffer
+	query := []rune{}
+	index := len(e.history)
+	match := ""
+	failed := false
+
+	// search keeps the previous match if nothing is found, like bash does
+	search := func(from int) {
+		for i := from; i >= 0; i-- {
+			if strings.Contains(e.history[i], string(query)) {
+				index = i
+				match = e.history[i]
+				failed = false
+
+				return
+			}
+		}
+
+		failed = true
+	}
+
+	for {
+		label := "reverse-i-search"
+
+		if failed {
+			label = "failed " + label
+		}
+
+		fmt.Fprintf(e.out, "\r(%s)`%s': %s\x1b[K", label, string(query), match)
+
+		key, err := e.readKey()
+
+		if err != nil {
+			return false, err
+		}
+
+		switch key {
+		case keyCtrlR:
+			search(index - 1)
+		case keyBackspace, keyDelete:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+				search(len(e.history) - 1)
+			}
+		case keyCtrlG, keyCtrlC:
+			state.buffer = original
+			state.cursor = len(original)
+
+			return false, nil
+		case keyEnter, '\n':
+			state.buffer = []rune(match)
+			state.cursor = len(state.buffer)
+
+			return true, nil
+		default:
+			if key >= ' ' {
+				query = append(query, key)
+				search(min(index, len(e.history)-1))
+				continue
+			}
+
+			state.buffer = []rune(match)
+			state.cursor = len(state.buffer)
+
+			return false, nil
+		}
+	}
+}
+
+// addHistory skips empty lines and repeated lines
+func (e *lineEditor) addHistory(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	if len(e.history) > 0 && e.history[len(e.history)-1] == line {
+		return
+	}
+
+	e.history = append(e.history, line)
+
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+
+	e.appendHistoryFile(line)
+}
+
+func (e *lineEditor) loadHistory() {
+	content, err := os.ReadFile(e.historyFile)
+
+	if err != nil {
+		return
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+}
+
+// appendHistoryFile saves each line as soon as it's entered, so the history survives a crash.
+// The history is a convenience, so the errors are ignored.
+func (e *lineEditor) appendHistoryFile(line string) {
+	if e.historyFile == "" {
+		return
+	}
+
+	file, err := os.OpenFile(e.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+
+	if err != nil {
+		return
+	}
+
+	defer file.Close()
+
+	fmt.Fprintln(file, line)
+}
+
+// wordStart returns the index where the word that ends at the cursor starts
+func wordStart(buffer []rune, cursor int, inWord func(rune) bool) int {
+	start := cursor
+
+	for start > 0 && inWord(buffer[start-1]) {
+		start--
+	}
+
+	return start
+}
+
+func isIdentifierRune(r rune) bool {
+	return isAlphaNumeric(r)
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+
+	for _, word := range words[1:] {
+		for !strings.HasPrefix(word, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
+
+// completions returns the keywords and the global names that start with the word
+func (l *Lox) completions(word string) []string {
+	candidates := []string{}
+
+	for keyword := range Keywords {
+		if strings.HasPrefix(keyword, word) {
+			candidates = append(candidates, keyword)
+		}
+	}
+
+	for name := range l.interpreter.globals.values {
+		if strings.HasPrefix(name, word) {
+			candidates = append(candidates, name)
+		}
+	}
+
+	sort.Strings(candidates)
+
+	return candidates
+}
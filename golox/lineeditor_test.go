@@ -0,0 +1,95 @@
+package golox
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func newTestEditor(input string, history ...string) *lineEditor {
+	return &lineEditor{
+		in:      bufio.NewReader(strings.NewReader(input)),
+		out:     io.Discard,
+		history: history,
+		complete: func(word string) []string {
+			candidates := []string{}
+
+			for _, name := range []string{"print", "printer", "var", "value"} {
+				if strings.HasPrefix(name, word) {
+					candidates = append(candidates, name)
+				}
+			}
+
+			return candidates
+		},
+	}
+}
+
+func TestLineEditorKeys(t *testing.T) {
+	editorTests := []struct {
+		name     string
+		input    string
+		history  []string
+		expected string
+	}{
+		{name: "plain line", input: "print 1;\r", expected: "print 1;\n"},
+		{name: "backspace", input: "print 12\x7f;\r", expected: "print 1;\n"},
+		{name: "cursor movement", input: "rint 1;\x1b[H" + "p\x1b[F\x1b[D" + "0\r", expected: "print 10;\n"},
+		{name: "delete forward", input: "abc\x01\x1b[3~\r", expected: "bc\n"},
+		{name: "kill to end", input: "print 1;\x01\x06\x06\x0b\r", expected: "pr\n"},
+		{name: "kill to start", input: "print 1;\x02\x02\x15\r", expected: "1;\n"},
+		{name: "delete word", input: "print value  \x17\r", expected: "print \n"},
+		{name: "history up", input: "\x1b[A\x1b[A\r", history: []string{"first", "second"}, expected: "first\n"},
+		{name: "history down restores the new line", input: "new\x1b[A\x1b[B\r", history: []string{"old"}, expected: "new\n"},
+		{name: "complete single candidate", input: "va\tr\r", expected: "var\n"},
+		{name: "complete common prefix", input: "pr\t\r", expected: "print\n"},
+		{name: "reverse search", input: "\x12sec\r", history: []string{"second", "first"}, expected: "second\n"},
+		{name: "reverse search next match", input: "\x12s\x12\r", history: []string{"second", "first", "last"}, expected: "first\n"},
+		{name: "reverse search keep for editing", input: "\x12fir\x1b[F!\r", history: []string{"first"}, expected: "first!\n"},
+		{name: "reverse search cancel", input: "draft\x12fir\x07\r", history: []string{"first"}, expected: "draft\n"},
+	}
+
+	for _, tt := range editorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			editor := newTestEditor(tt.input, tt.history...)
+
+			got, err := editor.readLine("> ")
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if got != tt.expected {
+				t.Errorf("got %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLineEditorControlKeys(t *testing.T) {
+	editor := newTestEditor("abc\x03\x04")
+
+	if _, err := editor.readLine("> "); err != errInterrupted {
+		t.Errorf("got %v, expected Ctrl-C to interrupt", err)
+	}
+
+	if _, err := editor.readLine("> "); err != io.EOF {
+		t.Errorf("got %v, expected Ctrl-D on an empty line to end the input", err)
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	editor := newTestEditor("one\rone\r\rtwo\r")
+
+	for i := 0; i < 4; i++ {
+		if _, err := editor.readLine("> "); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	// repeated and empty lines are not added
+	if strings.Join(editor.history, ",") != "one,two" {
+		t.Errorf("got %v, expected [one two]", editor.history)
+	}
+}
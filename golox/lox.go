@@ -4,70 +4,296 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 )
 
 type Lox struct {
 	lines  []string
 	source string
 	reader *bufio.Reader
+	// file is the name of the source file, used in the diagnostics
+	file string
+	// the same interpreter is used for the whole session, so the REPL keeps its state between the lines
+	interpreter *Interpteter
+	// editor is only used by the REPL when the input is a terminal
+	editor  *lineEditor
+	options Options
+}
+
+// Options change how the programs are run, the zero value runs them without tracing and limits
+type Options struct {
+	// Trace receives every statement, as an S-expression, before it's executed
+	Trace io.Writer
+	// MaxSteps stops the program with a StepLimitError after that many statements, 0 means no limit
+	MaxSteps int
+	// Args are the script arguments, available to the program as the args global
+	Args []string
+	// Integers makes the integer literals, eg. 42 or 0xFF, integers instead of floats.
+	// The arithmetic on two integers gives an integer, eg. 7 / 2 is 3.
+	Integers bool
+	// Stdout receives what the program prints, the REPL results and the output of the commands,
+	// Stderr receives the errors and Stdin is the input of the program. When nil, the process
+	// streams are used. The source itself is always read from the reader given to New.
+	Stdout io.Writer
+	Stderr io.Writer
+	Stdin  io.Reader
 }
 
 func New(r *bufio.Reader) *Lox {
-	return &Lox{reader: r, lines: make([]string, 0), source: ""}
+	lox := &Lox{reader: r, lines: make([]string, 0), source: ""}
+	lox.interpreter = lox.newInterpreter()
+
+	return lox
+}
+
+// NewFromFile is like New, but the diagnostics are reported with the file name
+func NewFromFile(file string, r *bufio.Reader) *Lox {
+	lox := New(r)
+	lox.file = file
+
+	return lox
+}
+
+// SetOptions starts a new session with the options, the variables defined so far are lost
+func (l *Lox) SetOptions(options Options) {
+	l.options = options
+	l.interpreter = l.newInterpreter()
 }
 
+func (l *Lox) newInterpreter() *Interpteter {
+	interpreter := NewInterpreter()
+	interpreter.trace = l.options.Trace
+	interpreter.maxSteps = l.options.MaxSteps
+	interpreter.globals.define("args", newArgs(l.options.Args))
+
+	if l.options.Stdout != nil {
+		interpreter.stdout = l.options.Stdout
+	}
+
+	if l.options.Stderr != nil {
+		interpreter.stderr = l.options.Stderr
+	}
+
+	if l.options.Stdin != nil {
+		interpreter.stdin = l.options.Stdin
+	}
+
+	return interpreter
+}
+
+// EnableLineEditing turns on the line editor in the REPL, with history and tab completion.
+// It has no effect if the input is not a terminal, eg. when the input is piped.
+func (l *Lox) EnableLineEditing(in *os.File, out io.Writer) {
+	l.editor = newTerminalEditor(in, out, l.completions)
+}
+
+// Run executes the source read from the reader. In interactive mode every statement is run
+// as soon as it's read and the errors are only reported, so the session can go on.
+// Otherwise the whole source is run at once and the first failure is returned as a
+// ScanError, ParseErrors or RuntimeError.
 func (l *Lox) Run(interactive bool) error {
+	if interactive {
+		return l.runPrompt()
+	}
+
+	return l.runFile()
+}
+
+func (l *Lox) runFile() error {
+	err := l.readSource()
+
+	if err != nil {
+		return err
+	}
+
+	err = l.run(l.file, l.source)
+
+	if err != nil {
+		l.report(l.file, l.source, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// PrintAst parses the source and prints its syntax tree, as S-expressions or as an indented tree
+func (l *Lox) PrintAst(indented bool) error {
+	err := l.readSource()
+
+	if err != nil {
+		return err
+	}
+
+	scanner := NewScanner(l.source)
+	tokens, err := scanner.ScanTokens()
+
+	if err == nil {
+		parser := NewParser(tokens)
+
+		var stmts []IStmt
+		stmts, err = parser.parse()
+
+		if err == nil && indented {
+			fmt.Fprint(l.interpreter.stdout, NewAstPrinter().PrintTree(stmts))
+		} else if err == nil {
+			fmt.Fprintln(l.interpreter.stdout, NewAstPrinter().Print(stmts))
+		}
+	}
+
+	if err != nil {
+		l.report(l.file, l.source, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Format reads the source and returns it in the canonical form, the syntax errors are reported
+func (l *Lox) Format() (string, error) {
+	err := l.readSource()
+
+	if err != nil {
+		return "", err
+	}
+
+	formatted, err := Format(l.source)
+
+	if err != nil {
+		l.report(l.file, l.source, err)
+
+		return "", err
+	}
+
+	return formatted, nil
+}
+
+// Check reports the errors the scanner, parser and resolver find in the source, without running it
+func (l *Lox) Check() error {
+	err := l.readSource()
+
+	if err != nil {
+		return err
+	}
+
+	_, err = l.compile(l.file, l.source, false)
+
+	if err != nil {
+		l.report(l.file, l.source, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// PrintTokens scans the source and prints its tokens, one per line
+func (l *Lox) PrintTokens() error {
+	err := l.readSource()
+
+	if err != nil {
+		return err
+	}
+
+	err = printTokens(l.interpreter.stdout, l.source)
+
+	if err != nil {
+		l.report(l.file, l.source, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Source is the source that was read so far
+func (l *Lox) Source() string {
+	return l.source
+}
+
+// readSource reads the whole input into the source
+func (l *Lox) readSource() error {
 	for {
 		line, err := l.reader.ReadString('\n')
 
+		// the last line doesn't have to end with a new line
+		l.source += line
+		l.lines = append(l.lines, line)
+
 		if err == io.EOF {
-			break
+			return nil
 		}
 
 		if err != nil {
 			return err
 		}
-
-		if interactive {
-			run(line)
-			continue
-		}
-
-		l.source += line
-
-		l.lines = append(l.lines, line)
 	}
+}
 
-	if !interactive {
-		run(l.source)
+func (l *Lox) run(file string, source string) error {
+	stmts, err := l.compile(file, source, false)
+
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return l.interpreter.interpret(stmts)
 }
 
-func run(source string) {
+// compile runs the scanner, parser and resolver on the source, the file is kept for the runtime errors
+func (l *Lox) compile(file string, source string, repl bool) ([]IStmt, error) {
 	scanner := NewScanner(source)
+	scanner.integers = l.options.Integers
+	scanner.origin.file = file
 
 	tokens, err := scanner.ScanTokens()
 
 	if err != nil {
-		panic(fmt.Sprintf("error while scanning %v", err))
+		return nil, err
 	}
 
 	parser := NewParser(tokens)
+	parser.repl = repl
 
-	expressions, err := parser.parse()
+	stmts, err := parser.parse()
 
 	if err != nil {
-		panic(fmt.Sprintf("error while parsing %v", err))
+		return nil, err
 	}
 
-	interpreter := NewInterpreter()
+	resolver := NewResolver()
 
-	err = interpreter.interpret(expressions)
+	err = resolver.resolve(stmts)
 
 	if err != nil {
-		panic(fmt.Sprintf("error while interpreting %v", err))
+		return nil, err
+	}
+
+	return stmts, nil
+}
+
+// report prints every diagnostic of the error with an excerpt of the source
+func (l *Lox) report(file string, source string, err error) {
+	diagnostics := Diagnostics(err)
+
+	if len(diagnostics) == 0 {
+		// the errors without a location only get the severity, like the diagnostics
+		fmt.Fprintf(l.interpreter.stderr, "%s: %v\n", ERROR_SEVERITY, err)
+
+		return
 	}
 
+	for _, diagnostic := range diagnostics {
+		diagnostic.File = file
+		excerpt := source
+
+		// a function declared by an earlier REPL input or by a loaded file fails in its own source
+		if diagnostic.origin != nil && diagnostic.origin.source != source {
+			diagnostic.File = diagnostic.origin.file
+			excerpt = diagnostic.origin.source
+		}
+
+		fmt.Fprintln(l.interpreter.stderr, diagnostic.Render(excerpt))
+	}
 }
@@ -0,0 +1,103 @@
+package golox
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunErrorTypes(t *testing.T) {
+	runTests := []struct {
+		name   string
+		source string
+		target any
+	}{
+		{name: "scan error", source: "print 1 @ 2;", target: new(*ScanError)},
+		{name: "parse error", source: "print (1;", target: new(ParseErrors)},
+		{name: "resolve error", source: "return 1;", target: new(ParseErrors)},
+		{name: "runtime error", source: "print -\"one\";", target: new(*RuntimeError)},
+	}
+
+	for _, tt := range runTests {
+		t.Run(tt.name, func(t *testing.T) {
+			lox := New(bufio.NewReader(strings.NewReader(tt.source)))
+			err := lox.run("", tt.source)
+
+			if !errors.As(err, tt.target) {
+				t.Errorf("got %T %v, expected %T", err, err, tt.target)
+			}
+		})
+	}
+}
+
+func TestInteractiveRunContinuesAfterErrors(t *testing.T) {
+	lox := New(bufio.NewReader(strings.NewReader("print 1 @ 2;\nprint (1;\nprint -\"one\";\n")))
+
+	if err := lox.Run(true); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var trace strings.Builder
+
+	lox := New(bufio.NewReader(strings.NewReader("")))
+	lox.SetOptions(Options{Trace: &trace, MaxSteps: 10, Args: []string{"a", "b"}})
+
+	err := lox.run("", "var n = args.length; var first = args.get(0); var missing = args.get(2);")
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	globals := lox.interpreter.globals.values
+
+	if globals["n"] != 2.0 || globals["first"] != "a" || globals["missing"] != nil {
+		t.Errorf("got n = %v, first = %v, missing = %v", globals["n"], globals["first"], globals["missing"])
+	}
+
+	if expected := "trace: (var n (. args length))\n"; !strings.HasPrefix(trace.String(), expected) {
+		t.Errorf("got trace %q, expected it to start with %q", trace.String(), expected)
+	}
+
+	err = lox.run("", "while (true) {}")
+
+	if !errors.As(err, new(*StepLimitError)) {
+		t.Errorf("got %T %v, expected a StepLimitError", err, err)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	var stdout, stderr strings.Builder
+
+	lox := New(bufio.NewReader(strings.NewReader("print \"a\" + 1;\n1 + 2\nprint nope;\n")))
+	lox.SetOptions(Options{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("")})
+
+	if err := lox.Run(true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if expected := "> a1\n> 3\n> > "; stdout.String() != expected {
+		t.Errorf("got stdout %q, expected %q", stdout.String(), expected)
+	}
+
+	if !strings.Contains(stderr.String(), "undefined variable 'nope'") {
+		t.Errorf("got stderr %q, expected the undefined variable error", stderr.String())
+	}
+}
+
+func TestReportErrorWithoutLocation(t *testing.T) {
+	var stderr strings.Builder
+
+	lox := New(bufio.NewReader(strings.NewReader("while (true) {}")))
+	lox.SetOptions(Options{MaxSteps: 3, Stderr: &stderr})
+
+	if err := lox.Run(false); !errors.As(err, new(*StepLimitError)) {
+		t.Fatalf("got %v, expected a StepLimitError", err)
+	}
+
+	if expected := "error: the program exceeded the limit of 3 steps\n"; stderr.String() != expected {
+		t.Errorf("got %q, expected %q", stderr.String(), expected)
+	}
+}
@@ -0,0 +1,146 @@
+package golox
+
+import (
+	"math"
+	"strconv"
+)
+
+// The Lox numbers are float64 values. When the integers are enabled, the integer literals are
+// int64 values instead and the arithmetic on two integers gives an integer, eg. 7 / 2 is 3.
+// An integer mixed with a float is converted to a float, eg. 7 / 2.0 is 3.5.
+
+func isNumber(value any) bool {
+	switch value.(type) {
+	case float64, int64:
+		return true
+	}
+
+	return false
+}
+
+func toFloat(value any) float64 {
+	if value, ok := value.(int64); ok {
+		return float64(value)
+	}
+
+	return value.(float64)
+}
+
+// toInt accepts the integers and the floats without a fraction, eg. an index
+func toInt(value any) (int64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return value, true
+	case float64:
+		if value == math.Trunc(value) && math.Abs(value) <= 1<<53 {
+			return int64(value), true
+		}
+	}
+
+	return 0, false
+}
+
+// arithmetic applies +, -, *, / or % to two numbers
+func arithmetic(operator Token, left any, right any) (any, error) {
+	a, leftIsInt := left.(int64)
+	b, rightIsInt := right.(int64)
+
+	if leftIsInt && rightIsInt {
+		switch operator.tokenType {
+		case PLUS:
+			return a + b, nil
+		case MINUS:
+			return a - b, nil
+		case STAR:
+			return a * b, nil
+		}
+
+		// unlike the floats, the integers don't have an infinity
+		if b == 0 {
+			return nil, NewRuntimeError(operator, "integer division by zero")
+		}
+
+		if operator.tokenType == SLASH {
+			return a / b, nil
+		}
+
+		return a % b, nil
+	}
+
+	x, y := toFloat(left), toFloat(right)
+
+	switch operator.tokenType {
+	case PLUS:
+		return x + y, nil
+	case MINUS:
+		return x - y, nil
+	case STAR:
+		return x * y, nil
+	case SLASH:
+		return x / y, nil
+	}
+
+	return math.Mod(x, y), nil
+}
+
+// compare applies >, >=, < or <= to two numbers
+func compare(operator Token, left any, right any) bool {
+	a, leftIsInt := left.(int64)
+	b, rightIsInt := right.(int64)
+
+	if !leftIsInt || !rightIsInt {
+		return compareFloats(operator, toFloat(left), toFloat(right))
+	}
+
+	switch operator.tokenType {
+	case GREATER:
+		return a > b
+	case GREATER_EQUAL:
+		return a >= b
+	case LESS:
+		return a < b
+	}
+
+	return a <= b
+}
+
+func compareFloats(operator Token, a float64, b float64) bool {
+	switch operator.tokenType {
+	case GREATER:
+		return a > b
+	case GREATER_EQUAL:
+		return a >= b
+	case LESS:
+		return a < b
+	}
+
+	return a <= b
+}
+
+func negate(value any) any {
+	if value, ok := value.(int64); ok {
+		return -value
+	}
+
+	return -value.(float64)
+}
+
+// formatNumber prints the floats without a trailing .0, eg. 3 instead of 3.0
+func formatNumber(value any) string {
+	if value, ok := value.(int64); ok {
+		return strconv.FormatInt(value, 10)
+	}
+
+	float := value.(float64)
+
+	// Go would print +Inf and -Inf
+	if math.IsInf(float, 0) {
+		if float > 0 {
+			return "Infinity"
+		}
+
+		return "-Infinity"
+	}
+
+	return strconv.FormatFloat(float, 'f', -1, 64)
+}
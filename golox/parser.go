@@ -1,24 +1,65 @@
 package golox
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
 
 /** Expressions:
 
-expression     → equality ;
+expression     → assignment ;
+assignment     → ( call "." )? IDENTIFIER "=" assignment
+               | logic_or ;
+logic_or       → logic_and ( "or" logic_and )* ;
+logic_and      → equality ( "and" equality )* ;
 equality       → comparison ( ( "!=" | "==" ) comparison )* ;
 comparison     → term ( ( ">" | ">=" | "<" | "<=" ) term )* ;
 term           → factor ( ( "-" | "+" ) factor )* ;
-factor         → unary ( ( "/" | "*" ) unary )* ;
-unary          → ( "!" | "-" ) unary | primary ;
-primary        → NUMBER | STRING | "true" | "false" | "nil" | "(" expression ")" ;
+factor         → unary ( ( "/" | "*" | "%" ) unary )* ;
+unary          → ( "!" | "-" ) unary | call ;
+call           → primary ( "(" arguments? ")" | "." IDENTIFIER )* ;
+arguments      → expression ( "," expression )* ;
+primary        → NUMBER | STRING | "true" | "false" | "nil" | "this"
+               | interpolation
+               | "(" expression ")"
+               | IDENTIFIER
+               | "super" "." IDENTIFIER ;
+interpolation  → INTERPOLATION expression ( INTERPOLATION expression )* STRING ;
 */
 
 /** Statements
 
-program        → statement* EOF ;
+program        → declaration* EOF ;
+
+declaration    → classDecl
+               | funDecl
+               | varDecl
+               | statement ;
+
+classDecl      → "class" IDENTIFIER ( "<" IDENTIFIER )?
+                 "{" function* "}" ;
+funDecl        → "fun" function ;
+function       → IDENTIFIER "(" parameters? ")" block ;
+parameters     → IDENTIFIER ( "," IDENTIFIER )* ;
+
+varDecl        → "var" IDENTIFIER ( "=" expression )? ";" ;
 
 statement      → exprStmt
-               | printStmt ;
+               | forStmt
+               | ifStmt
+               | printStmt
+               | returnStmt
+               | whileStmt
+               | block ;
+
+block          → "{" declaration* "}" ;
+forStmt        → "for" "(" ( varDecl | exprStmt | ";" )
+                 expression? ";"
+                 expression? ")" statement ;
+ifStmt         → "if" "(" expression ")" statement
+               ( "else" statement )? ;
+returnStmt     → "return" expression? ";" ;
+whileStmt      → "while" "(" expression ")" statement ;
 
 exprStmt       → expression ";" ;
 printStmt      → "print" expression ";" ;
@@ -29,39 +70,436 @@ printStmt      → "print" expression ";" ;
 * Each rule becomes a function
  */
 
+// maxArguments is the limit of arguments for a call and parameters for a function
+const maxArguments = 255
+
 type Parser struct {
 	tokens  []Token
 	current int
+	// errors collects all syntax errors, the parser doesn't stop on the first one
+	errors ParseErrors
+	// repl allows the last expression statement to omit the semicolon, eg. "1 + 2"
+	repl bool
+	// layout records where the statements are in the source, it's only set by the formatter
+	layout *layout
 }
 
 func NewParser(t []Token) Parser {
-	return Parser{t, 0}
+	return Parser{tokens: t, current: 0}
 }
 
+// parse returns all the statements it managed to parse, even if there are errors
 func (p *Parser) parse() ([]IStmt, error) {
 	statements := []IStmt{}
 
 	for !p.isAtEnd() {
-		stmt, err := p.statement()
+		stmt := p.declaration()
+
+		if stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+
+	if len(p.errors) > 0 {
+		return statements, p.errors
+	}
+
+	return statements, nil
+}
+
+// declaration is where the parser recovers from the errors. The error is recorded and
+// the tokens are discarded until the start of the next statement.
+func (p *Parser) declaration() IStmt {
+	start := p.peek()
+	stmt, err := p.declarationOrError()
+
+	if err != nil {
+		p.report(err)
+		p.synchronize()
+
+		return nil
+	}
+
+	p.recordStatement(stmt, start)
+
+	return stmt
+}
+
+func (p *Parser) declarationOrError() (IStmt, error) {
+	if p.match(CLASS) {
+		return p.classDeclaration()
+	}
+
+	if p.match(FUN) {
+		function, err := p.function("function")
 
 		if err != nil {
 			return nil, err
 		}
 
-		statements = append(statements, stmt)
+		return function, nil
 	}
 
-	return statements, nil
+	if p.match(VAR) {
+		return p.varDeclaration()
+	}
+
+	return p.statement()
+}
+
+func (p *Parser) classDeclaration() (IStmt, error) {
+	name, err := p.consume(IDENTIFIER, "expected class name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *VariableExpr
+
+	if p.match(LESS) {
+		superclassName, err := p.consume(IDENTIFIER, "expected superclass name")
+
+		if err != nil {
+			return nil, err
+		}
+
+		superclass = NewVariableExpr(*superclassName)
+	}
+
+	open, err := p.consume(LEFT_BRACE, "expected '{' before class body")
+
+	if err != nil {
+		return nil, err
+	}
+
+	methods := []*FunctionStmt{}
+
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		start := p.peek()
+		method, err := p.function("method")
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.recordStatement(method, start)
+		methods = append(methods, method)
+	}
+
+	_, err = p.consume(RIGHT_BRACE, "expected '}' after class body")
+
+	if err != nil {
+		return nil, err
+	}
+
+	class := NewClassStmt(*name, superclass, methods)
+	p.recordBraces(class, *open)
+
+	return class, nil
+}
+
+// function parses both functions and methods, the kind is used for the error messages
+func (p *Parser) function(kind string) (*FunctionStmt, error) {
+	name, err := p.consume(IDENTIFIER, fmt.Sprintf("expected %s name", kind))
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(LEFT_PAREN, fmt.Sprintf("expected '(' after %s name", kind))
+
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := []Token{}
+
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(parameters) >= maxArguments {
+				p.report(p.error(p.peek(), fmt.Sprintf("can't have more than %d parameters", maxArguments)))
+			}
+
+			param, err := p.consume(IDENTIFIER, "expected parameter name")
+
+			if err != nil {
+				return nil, err
+			}
+
+			parameters = append(parameters, *param)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	_, err = p.consume(RIGHT_PAREN, "expected ')' after parameters")
+
+	if err != nil {
+		return nil, err
+	}
+
+	open, err := p.consume(LEFT_BRACE, fmt.Sprintf("expected '{' before %s body", kind))
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.block()
+
+	if err != nil {
+		return nil, err
+	}
+
+	function := NewFunctionStmt(*name, parameters, body)
+	p.recordBraces(function, *open)
+
+	return function, nil
+}
+
+func (p *Parser) varDeclaration() (IStmt, error) {
+	name, err := p.consume(IDENTIFIER, "expected variable name")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var initializer IExpr
+
+	if p.match(EQUAL) {
+		initializer, err = p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(SEMICOLON, "expected ';' after variable declaration")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewVarStmt(*name, initializer), nil
 }
 
 func (p *Parser) statement() (IStmt, error) {
+	if p.match(FOR) {
+		return p.forStatement()
+	}
+
+	if p.match(IF) {
+		return p.ifStatement()
+	}
+
+	if p.match(WHILE) {
+		return p.whileStatement()
+	}
+
 	if p.match(PRINT) {
 		return p.printStatement()
 	}
 
+	if p.match(RETURN) {
+		return p.returnStatement()
+	}
+
+	if p.match(LEFT_BRACE) {
+		open := p.prevoius()
+		statements, err := p.block()
+
+		if err != nil {
+			return nil, err
+		}
+
+		block := NewBlockStmt(statements)
+		p.recordBraces(block, open)
+
+		return block, nil
+	}
+
 	return p.expressionStatement()
 }
 
+// block returns the list of statements instead of a BlockStmt, so it can be reused for function bodies
+func (p *Parser) block() ([]IStmt, error) {
+	statements := []IStmt{}
+
+	for !p.check(RIGHT_BRACE) && !p.isAtEnd() {
+		stmt := p.declaration()
+
+		if stmt != nil {
+			statements = append(statements, stmt)
+		}
+	}
+
+	_, err := p.consume(RIGHT_BRACE, "expected '}' after block")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+}
+
+// forStatement is desugared into a while loop, the ForStmt only keeps the original clauses for the printers:
+//
+//	{
+//	  initializer;
+//	  while (condition) {
+//	    body;
+//	    increment;
+//	  }
+//	}
+func (p *Parser) forStatement() (IStmt, error) {
+	_, err := p.consume(LEFT_PAREN, "expected '(' after 'for'")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var initializer IStmt
+
+	if p.match(SEMICOLON) {
+		initializer = nil
+	} else if p.match(VAR) {
+		initializer, err = p.varDeclaration()
+	} else {
+		initializer, err = p.expressionStatement()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var condition IExpr
+
+	if !p.check(SEMICOLON) {
+		condition, err = p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(SEMICOLON, "expected ';' after loop condition")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var increment IExpr
+
+	if !p.check(RIGHT_PAREN) {
+		increment, err = p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(RIGHT_PAREN, "expected ')' after for clauses")
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+
+	if err != nil {
+		return nil, err
+	}
+
+	desugared := body
+
+	if increment != nil {
+		desugared = NewBlockStmt([]IStmt{desugared, NewExpressionStmt(increment)})
+	}
+
+	// an omitted condition makes an infinite loop
+	loopCondition := condition
+
+	if loopCondition == nil {
+		loopCondition = NewLiteralExpr(true)
+	}
+
+	desugared = NewWhileStmt(loopCondition, desugared)
+
+	if initializer != nil {
+		desugared = NewBlockStmt([]IStmt{initializer, desugared})
+	}
+
+	return NewForStmt(initializer, condition, increment, body, desugared), nil
+}
+
+func (p *Parser) ifStatement() (IStmt, error) {
+	_, err := p.consume(LEFT_PAREN, "expected '(' after 'if'")
+
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(RIGHT_PAREN, "expected ')' after if condition")
+
+	if err != nil {
+		return nil, err
+	}
+
+	thenBranch, err := p.statement()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the else is bound to the nearest if that precedes it
+	var elseBranch IStmt
+
+	if p.match(ELSE) {
+		elseBranch, err = p.statement()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewIfStmt(condition, thenBranch, elseBranch), nil
+}
+
+func (p *Parser) whileStatement() (IStmt, error) {
+	_, err := p.consume(LEFT_PAREN, "expected '(' after 'while'")
+
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.expression()
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(RIGHT_PAREN, "expected ')' after condition")
+
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWhileStmt(condition, body), nil
+}
+
 func (p *Parser) printStatement() (IStmt, error) {
 	expr, err := p.expression()
 
@@ -70,11 +508,38 @@ func (p *Parser) printStatement() (IStmt, error) {
 	}
 
 	// the print token is already matched in the statement fn
-	p.consume(SEMICOLON, "expected ';' after a valye")
+	_, err = p.consume(SEMICOLON, "expected ';' after value")
+
+	if err != nil {
+		return nil, err
+	}
 
 	return NewPrintStmt(expr), nil
 }
 
+func (p *Parser) returnStatement() (IStmt, error) {
+	keyword := p.prevoius()
+
+	var value IExpr
+	var err error
+
+	if !p.check(SEMICOLON) {
+		value, err = p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(SEMICOLON, "expected ';' after return value")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReturnStmt(keyword, value), nil
+}
+
 func (p *Parser) expressionStatement() (IStmt, error) {
 	expr, err := p.expression()
 
@@ -82,14 +547,95 @@ func (p *Parser) expressionStatement() (IStmt, error) {
 		return nil, err
 	}
 
-	// the print token is already matched in the statement fn
-	p.consume(SEMICOLON, "expected ';' after a valye")
+	if p.repl && p.isAtEnd() {
+		return NewExpressionStmt(expr), nil
+	}
+
+	_, err = p.consume(SEMICOLON, "expected ';' after expression")
+
+	if err != nil {
+		return nil, err
+	}
 
 	return NewExpressionStmt(expr), nil
 }
 
 func (p *Parser) expression() (IExpr, error) {
-	return p.equality()
+	return p.assignment()
+}
+
+func (p *Parser) assignment() (IExpr, error) {
+	expr, err := p.or()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(EQUAL) {
+		equals := p.prevoius()
+		value, err := p.assignment()
+
+		if err != nil {
+			return nil, err
+		}
+
+		// the left-hand side is parsed as an expression and only then checked
+		// if it's a valid assignment target
+		if variable, ok := expr.(*VariableExpr); ok {
+			return NewAssignExpr(variable.name, value), nil
+		}
+
+		if get, ok := expr.(*GetExpr); ok {
+			return NewSetExpr(get.object, get.name, value), nil
+		}
+
+		// the parser is not in a confused state, so there is no need to synchronize
+		p.report(p.error(equals, "invalid assignment target"))
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) or() (IExpr, error) {
+	expr, err := p.and()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(OR) {
+		operator := p.prevoius()
+		right, err := p.and()
+
+		if err != nil {
+			return nil, err
+		}
+
+		expr = NewLogicalExpr(expr, operator, right)
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) and() (IExpr, error) {
+	expr, err := p.equality()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for p.match(AND) {
+		operator := p.prevoius()
+		right, err := p.equality()
+
+		if err != nil {
+			return nil, err
+		}
+
+		expr = NewLogicalExpr(expr, operator, right)
+	}
+
+	return expr, nil
 }
 
 func (p *Parser) equality() (IExpr, error) {
@@ -162,7 +708,7 @@ func (p *Parser) factor() (IExpr, error) {
 		return nil, err
 	}
 
-	for p.match(SLASH, STAR) {
+	for p.match(SLASH, STAR, PERCENT) {
 		operator := p.prevoius()
 		right, err := p.unary()
 
@@ -177,7 +723,7 @@ func (p *Parser) factor() (IExpr, error) {
 }
 
 func (p *Parser) unary() (IExpr, error) {
-	if p.match(BANG, BANG_EQUAL) {
+	if p.match(BANG, MINUS) {
 		operator := p.prevoius()
 		right, err := p.unary()
 
@@ -188,7 +734,70 @@ func (p *Parser) unary() (IExpr, error) {
 		return NewUnaryExpr(operator, right), nil
 	}
 
-	return p.primary()
+	return p.call()
+}
+
+func (p *Parser) call() (IExpr, error) {
+	expr, err := p.primary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the loop handles curried calls like fn(1)(2) and chained properties like a.b.c()
+	for {
+		if p.match(LEFT_PAREN) {
+			expr, err = p.finishCall(expr)
+
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match(DOT) {
+			name, err := p.consume(IDENTIFIER, "expected property name after '.'")
+
+			if err != nil {
+				return nil, err
+			}
+
+			expr = NewGetExpr(expr, *name)
+		} else {
+			break
+		}
+	}
+
+	return expr, nil
+}
+
+func (p *Parser) finishCall(callee IExpr) (IExpr, error) {
+	arguments := []IExpr{}
+
+	if !p.check(RIGHT_PAREN) {
+		for {
+			if len(arguments) >= maxArguments {
+				p.report(p.error(p.peek(), fmt.Sprintf("can't have more than %d arguments", maxArguments)))
+			}
+
+			argument, err := p.expression()
+
+			if err != nil {
+				return nil, err
+			}
+
+			arguments = append(arguments, argument)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	paren, err := p.consume(RIGHT_PAREN, "expected ')' after arguments")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCallExpr(callee, *paren, arguments), nil
 }
 
 func (p *Parser) primary() (IExpr, error) {
@@ -205,7 +814,37 @@ func (p *Parser) primary() (IExpr, error) {
 	}
 
 	if p.match(NUMBER, STRING) {
-		return NewLiteralExpr(p.prevoius().literal), nil
+		return p.literal(), nil
+	}
+
+	if p.match(INTERPOLATION) {
+		return p.interpolation()
+	}
+
+	if p.match(SUPER) {
+		keyword := p.prevoius()
+
+		_, err := p.consume(DOT, "expected '.' after 'super'")
+
+		if err != nil {
+			return nil, err
+		}
+
+		method, err := p.consume(IDENTIFIER, "expected superclass method name")
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewSuperExpr(keyword, *method), nil
+	}
+
+	if p.match(THIS) {
+		return NewThisExpr(p.prevoius()), nil
+	}
+
+	if p.match(IDENTIFIER) {
+		return NewVariableExpr(p.prevoius()), nil
 	}
 
 	if p.match(LEFT_PAREN) {
@@ -215,12 +854,93 @@ func (p *Parser) primary() (IExpr, error) {
 			return nil, err
 		}
 
-		p.consume(RIGHT_PAREN, "expect ')' after expression.")
+		_, err = p.consume(RIGHT_PAREN, "expected ')' after expression")
+
+		if err != nil {
+			return nil, err
+		}
 
 		return NewGroupingExpr(expr), nil
 	}
 
-	return nil, fmt.Errorf("expected expression")
+	return nil, p.error(p.peek(), "expected expression")
+}
+
+// recordStatement remembers the first and the last token of a statement that was just parsed
+func (p *Parser) recordStatement(stmt IStmt, start Token) {
+	if p.layout != nil {
+		p.layout.starts[stmt] = start
+		p.layout.ends[stmt] = p.prevoius()
+	}
+}
+
+// recordBraces remembers the braces of a body, the '}' was just consumed.
+// The comments between the braces belong to the body.
+func (p *Parser) recordBraces(stmt IStmt, open Token) {
+	if p.layout != nil {
+		p.layout.openingBraces[stmt] = open
+		p.layout.closingBraces[stmt] = p.prevoius()
+	}
+}
+
+// literal creates the literal from the previous token, keeping the token for the formatter
+func (p *Parser) literal() *LiteralExpr {
+	token := p.prevoius()
+	literal := NewLiteralExpr(token.literal)
+	literal.token = &token
+
+	return literal
+}
+
+// interpolation parses a string with embedded expressions, the scanner splits "a ${b} c ${d} e"
+// into INTERPOLATION("a ") b INTERPOLATION(" c ") d STRING(" e")
+func (p *Parser) interpolation() (IExpr, error) {
+	parts := []IExpr{p.literal()}
+
+	for {
+		expr, err := p.expression()
+
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, expr)
+
+		if p.match(INTERPOLATION) {
+			parts = append(parts, p.literal())
+			continue
+		}
+
+		_, err = p.consume(STRING, "expected '}' after the interpolated expression")
+
+		if err != nil {
+			return nil, err
+		}
+
+		parts = append(parts, p.literal())
+
+		return NewInterpolationExpr(parts, concatenation(parts)), nil
+	}
+}
+
+// concatenation desugars the parts of an interpolated string into a chain of +, the first
+// part is a string, so + converts the values of the expressions to strings
+func concatenation(parts []IExpr) IExpr {
+	first := parts[0].(*LiteralExpr)
+	plus := NewToken(PLUS, "+", nil, first.token.line)
+
+	var expr IExpr = first
+
+	for _, part := range parts[1:] {
+		// the empty strings between the expressions don't change the result
+		if literal, ok := part.(*LiteralExpr); ok && literal.value == "" {
+			continue
+		}
+
+		expr = NewBinaryExpr(expr, plus, part)
+	}
+
+	return expr
 }
 
 func (p *Parser) consume(t TokenType, msg string) (*Token, error) {
@@ -230,7 +950,41 @@ func (p *Parser) consume(t TokenType, msg string) (*Token, error) {
 		return &token, nil
 	}
 
-	return nil, fmt.Errorf("error in line %d: %s", p.peek().line, msg)
+	return nil, p.error(p.peek(), msg)
+}
+
+func (p *Parser) error(token Token, message string) error {
+	return NewParseError(token, message)
+}
+
+func (p *Parser) report(err error) {
+	var parseErr *ParseError
+
+	if errors.As(err, &parseErr) {
+		p.errors = append(p.errors, parseErr)
+		return
+	}
+
+	p.errors = append(p.errors, &ParseError{message: err.Error(), token: p.peek()})
+}
+
+// synchronize discards tokens until it reaches a statement boundary: right after
+// a semicolon or right before a keyword that starts a statement
+func (p *Parser) synchronize() {
+	p.advance()
+
+	for !p.isAtEnd() {
+		if p.prevoius().tokenType == SEMICOLON {
+			return
+		}
+
+		switch p.peek().tokenType {
+		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN:
+			return
+		}
+
+		p.advance()
+	}
 }
 
 func (p *Parser) match(tokens ...TokenType) bool {
@@ -0,0 +1,110 @@
+package golox
+
+import (
+	"errors"
+	"testing"
+)
+
+func parseSource(t *testing.T, source string) ([]IStmt, error) {
+	t.Helper()
+
+	scanner := NewScanner(source)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		t.Fatalf("unexpected scan error %v", err)
+	}
+
+	parser := NewParser(tokens)
+
+	return parser.parse()
+}
+
+func TestParseErrorsAreCollected(t *testing.T) {
+	source := `var a = ;
+print a;
+print (1 + 2;
+fun (x) {}
+var b = 1;
+1 = b;
+print b`
+
+	stmts, err := parseSource(t, source)
+
+	var parseErrors ParseErrors
+
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("got %v, expected parse errors", err)
+	}
+
+	expected := []string{
+		"error in line 1 at ';': expected expression",
+		"error in line 3 at ';': expected ')' after expression",
+		"error in line 4 at '(': expected function name",
+		"error in line 6 at '=': invalid assignment target",
+		"error in line 7 at end: expected ';' after value",
+	}
+
+	if len(parseErrors) != len(expected) {
+		t.Fatalf("got %d errors, expected %d:\n%v", len(parseErrors), len(expected), err)
+	}
+
+	for i, message := range expected {
+		if parseErrors[i].Error() != message {
+			t.Errorf("got %q, expected %q", parseErrors[i].Error(), message)
+		}
+	}
+
+	// print a; var b = 1; 1 = b;
+	if len(stmts) != 3 {
+		t.Errorf("got %d statements, expected 3", len(stmts))
+	}
+}
+
+func TestParseErrorInsideBlock(t *testing.T) {
+	stmts, err := parseSource(t, "{\n  var = 1;\n  print 2;\n}\nprint 3;")
+
+	var parseErrors ParseErrors
+
+	if !errors.As(err, &parseErrors) || len(parseErrors) != 1 {
+		t.Fatalf("got %v, expected a single parse error", err)
+	}
+
+	// the parser recovers inside the block, so both the block and the last print are parsed
+	if len(stmts) != 2 {
+		t.Fatalf("got %d statements, expected 2", len(stmts))
+	}
+
+	if block, ok := stmts[0].(*BlockStmt); !ok || len(block.statements) != 1 {
+		t.Errorf("got %v, expected a block with a single statement", stmts[0])
+	}
+}
+
+func TestReplTrailingExpression(t *testing.T) {
+	scanner := NewScanner("var a = 1;\na + 2")
+	tokens, _ := scanner.ScanTokens()
+
+	parser := NewParser(tokens)
+	parser.repl = true
+
+	stmts, err := parser.parse()
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, ok := stmts[1].(*ExpressionStmt); len(stmts) != 2 || !ok {
+		t.Errorf("got %v, expected the last statement to be an expression", stmts)
+	}
+
+	// only the expression statements can omit the semicolon
+	scanner = NewScanner("print 1")
+	tokens, _ = scanner.ScanTokens()
+
+	parser = NewParser(tokens)
+	parser.repl = true
+
+	if _, err := parser.parse(); err == nil {
+		t.Errorf("expected an error for a print statement without a semicolon")
+	}
+}
@@ -0,0 +1,147 @@
+package golox
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	prompt             = "> "
+	continuationPrompt = "... "
+)
+
+// runPrompt is the REPL. The input is collected until it forms complete statements,
+// so a statement, like a function declaration, can span multiple lines.
+func (l *Lox) runPrompt() error {
+	input := ""
+
+	for {
+		currentPrompt := prompt
+
+		if input != "" {
+			currentPrompt = continuationPrompt
+		}
+
+		line, err := l.readLine(currentPrompt)
+
+		// Ctrl-C discards the statement that is being typed
+		if err == errInterrupted {
+			input = ""
+			continue
+		}
+
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		// the meta-commands are always a single line
+		if input == "" && strings.HasPrefix(line, ":") {
+			l.runCommand(strings.TrimSpace(line))
+
+			if err == io.EOF {
+				return nil
+			}
+
+			continue
+		}
+
+		input += line
+
+		if err == io.EOF {
+			// run whatever is left, the incomplete input is reported as an error
+			if input != "" {
+				l.runInteractive(input)
+			}
+
+			return nil
+		}
+
+		if isIncomplete(input) {
+			continue
+		}
+
+		l.runInteractive(input)
+		input = ""
+	}
+}
+
+func (l *Lox) readLine(prompt string) (string, error) {
+	if l.editor != nil {
+		return l.editor.readLine(prompt)
+	}
+
+	fmt.Fprint(l.interpreter.stdout, prompt)
+
+	return l.reader.ReadString('\n')
+}
+
+func (l *Lox) runInteractive(input string) {
+	err := l.eval(input)
+
+	if err != nil {
+		l.report(l.file, input, err)
+	}
+}
+
+// eval is like run, but when the input is a single expression its value is printed
+func (l *Lox) eval(input string) error {
+	stmts, err := l.compile(l.file, input, true)
+
+	if err != nil {
+		return err
+	}
+
+	if len(stmts) == 1 {
+		if stmt, ok := stmts[0].(*ExpressionStmt); ok {
+			value, err := l.interpreter.interpretExpression(stmt)
+
+			if err != nil {
+				return err
+			}
+
+			text, err := l.interpreter.stringify(value)
+
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintln(l.interpreter.stdout, text)
+
+			return err
+		}
+	}
+
+	return l.interpreter.interpret(stmts)
+}
+
+// isIncomplete checks if the input ends in the middle of a string, or if it has
+// more opening parentheses or braces than closing ones
+func isIncomplete(input string) bool {
+	scanner := NewScanner(input)
+	tokens, err := scanner.ScanTokens()
+
+	var scanErr *ScanError
+
+	if errors.As(err, &scanErr) {
+		return scanErr.incomplete
+	}
+
+	if err != nil {
+		return false
+	}
+
+	depth := 0
+
+	for _, token := range tokens {
+		switch token.tokenType {
+		case LEFT_PAREN, LEFT_BRACE:
+			depth++
+		case RIGHT_PAREN, RIGHT_BRACE:
+			depth--
+		}
+	}
+
+	return depth > 0
+}
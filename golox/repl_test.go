@@ -0,0 +1,133 @@
+package golox
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestIsIncomplete(t *testing.T) {
+	incompleteTests := []struct {
+		input    string
+		expected bool
+	}{
+		{input: "print 1;\n", expected: false},
+		{input: "fun add(a, b) {\n", expected: true},
+		{input: "fun add(a, b) {\n  return a + b;\n}\n", expected: false},
+		{input: "print add(1,\n", expected: true},
+		{input: "print \"multi\n", expected: true},
+		{input: "print \"multi\nline\";\n", expected: false},
+		// too many closing braces can't be fixed by reading more lines
+		{input: "}\n", expected: false},
+		{input: "print @;\n", expected: false},
+	}
+
+	for _, tt := range incompleteTests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := isIncomplete(tt.input); got != tt.expected {
+				t.Errorf("got %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPromptKeepsSessionState(t *testing.T) {
+	input := "var a = 1;\nfun inc() {\n  a = a + 1;\n}\ninc();\ninc();\n"
+	lox := New(bufio.NewReader(strings.NewReader(input)))
+
+	if err := lox.Run(true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got, _ := lox.interpreter.globals.get(NewToken(IDENTIFIER, "a", "a", 0))
+
+	if got != 3.0 {
+		t.Errorf("got %v, expected %v", got, 3.0)
+	}
+}
+
+func TestReplCommands(t *testing.T) {
+	file := t.TempDir() + "/lib.lox"
+
+	if err := os.WriteFile(file, []byte("var loaded = \"yes\";\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lox := New(bufio.NewReader(strings.NewReader(":load " + file + "\n")))
+
+	if err := lox.Run(true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	name := NewToken(IDENTIFIER, "loaded", "loaded", 0)
+
+	if got, _ := lox.interpreter.globals.get(name); got != "yes" {
+		t.Errorf("got %v, expected the loaded variable", got)
+	}
+
+	lox.runCommand(":reset")
+
+	if _, err := lox.interpreter.globals.get(name); err == nil {
+		t.Errorf("expected the variable to be cleared by :reset")
+	}
+}
+
+func TestReplEchoesLoxValues(t *testing.T) {
+	var stdout strings.Builder
+
+	lox := New(bufio.NewReader(strings.NewReader("nil\n1 + 2\n3.0\n0.5\ntrue\n\"text\"\nfun f() {}\nf\nclass A {}\nA()\n")))
+	lox.SetOptions(Options{Stdout: &stdout})
+
+	if err := lox.Run(true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := "> nil\n> 3\n> 3\n> 0.5\n> true\n> text\n> > <fn f>\n> > A instance\n> "
+
+	if stdout.String() != expected {
+		t.Errorf("got %q, expected %q", stdout.String(), expected)
+	}
+}
+
+func TestReplStepLimitPerLine(t *testing.T) {
+	var stdout, stderr, trace strings.Builder
+
+	input := "fun f() { var a = 1; var b = 2; return a; }\nf()\nf()\nf()\n"
+	lox := New(bufio.NewReader(strings.NewReader(input)))
+	lox.SetOptions(Options{MaxSteps: 6, Stdout: &stdout, Stderr: &stderr, Trace: &trace})
+
+	if err := lox.Run(true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if stderr.String() != "" {
+		t.Errorf("got the errors %q, expected the limit to apply to each line", stderr.String())
+	}
+
+	if strings.Count(stdout.String(), "1\n") != 3 {
+		t.Errorf("got %q, expected f() to print 1 three times", stdout.String())
+	}
+
+	if strings.Count(trace.String(), "trace: (; (call f))\n") != 3 {
+		t.Errorf("got the trace %q, expected the expressions to be traced", trace.String())
+	}
+}
+
+func TestReplErrorInEarlierInput(t *testing.T) {
+	var stdout, stderr strings.Builder
+
+	lox := New(bufio.NewReader(strings.NewReader("fun f() {\n  return -\"a\";\n}\nf();\n")))
+	lox.SetOptions(Options{Stdout: &stdout, Stderr: &stderr})
+
+	if err := lox.Run(true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// the excerpt comes from the input that declared f, not from the call
+	expected := "error: a operand must be a number\n --> 2:10\n  |\n2 |   return -\"a\";\n  |          ^\n\n"
+
+	if stderr.String() != expected {
+		t.Errorf("got %q, expected %q", stderr.String(), expected)
+	}
+}
@@ -0,0 +1,381 @@
+package golox
+
+type FunctionType int
+
+const (
+	NONE_FUNCTION FunctionType = iota
+	FUNCTION
+	INITIALIZER
+	METHOD
+)
+
+type ClassType int
+
+const (
+	NONE_CLASS ClassType = iota
+	CLASS_TYPE
+	SUBCLASS_TYPE
+)
+
+// Resolver is a static analysis pass that runs between the parser and the interpreter.
+// It visits every node once and stores in each local variable expression how many scopes
+// there are between the expression and the scope where the variable is declared.
+type Resolver struct {
+	// the stack of local block scopes, the global scope is not tracked.
+	// The value marks if the variable's initializer is already resolved.
+	scopes          []map[string]bool
+	currentFunction FunctionType
+	currentClass    ClassType
+	// resolve errors are reported the same way as the syntax errors
+	errors ParseErrors
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		scopes:          []map[string]bool{},
+		currentFunction: NONE_FUNCTION,
+		currentClass:    NONE_CLASS,
+	}
+}
+
+// resolve doesn't stop on the first error, all of them are reported together
+func (r *Resolver) resolve(stmts []IStmt) error {
+	r.resolveStatements(stmts)
+
+	if len(r.errors) > 0 {
+		return r.errors
+	}
+
+	return nil
+}
+
+func (r *Resolver) resolveStatements(stmts []IStmt) {
+	for _, stmt := range stmts {
+		r.resolveStmt(stmt)
+	}
+}
+
+func (r *Resolver) resolveStmt(stmt IStmt) {
+	stmt.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(expr IExpr) {
+	expr.Accept(r)
+}
+
+func (r *Resolver) resolveFunction(function *FunctionStmt, functionType FunctionType) {
+	enclosingFunction := r.currentFunction
+	r.currentFunction = functionType
+
+	r.beginScope()
+
+	for _, param := range function.params {
+		r.declare(param)
+		r.define(param)
+	}
+
+	r.resolveStatements(function.body)
+
+	r.endScope()
+
+	r.currentFunction = enclosingFunction
+}
+
+func (r *Resolver) beginScope() {
+	r.scopes = append(r.scopes, map[string]bool{})
+}
+
+func (r *Resolver) endScope() {
+	r.scopes = r.scopes[:len(r.scopes)-1]
+}
+
+// declare adds the variable to the innermost scope, but marks it as not ready yet
+func (r *Resolver) declare(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+
+	scope := r.scopes[len(r.scopes)-1]
+
+	if _, ok := scope[name.lexeme]; ok {
+		r.error(name, "already a variable with this name in this scope")
+	}
+
+	scope[name.lexeme] = false
+}
+
+// define marks the variable as initialized and available for use
+func (r *Resolver) define(name Token) {
+	if len(r.scopes) == 0 {
+		return
+	}
+
+	r.scopes[len(r.scopes)-1][name.lexeme] = true
+}
+
+// resolveLocal starts at the innermost scope and works outwards. If the variable
+// is not found, it's assumed to be global and left unresolved.
+func (r *Resolver) resolveLocal(local *local, name Token) {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if _, ok := r.scopes[i][name.lexeme]; ok {
+			local.resolve(len(r.scopes) - 1 - i)
+			return
+		}
+	}
+}
+
+func (r *Resolver) error(token Token, message string) {
+	r.errors = append(r.errors, &ParseError{message, token})
+}
+
+// VisitBlockStmt implements IStmtVisitor.
+func (r *Resolver) VisitBlockStmt(stmt *BlockStmt) error {
+	r.beginScope()
+	r.resolveStatements(stmt.statements)
+	r.endScope()
+
+	return nil
+}
+
+// VisitClassStmt implements IStmtVisitor.
+func (r *Resolver) VisitClassStmt(stmt *ClassStmt) error {
+	enclosingClass := r.currentClass
+	r.currentClass = CLASS_TYPE
+
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	if stmt.superclass != nil {
+		if stmt.name.lexeme == stmt.superclass.name.lexeme {
+			r.error(stmt.superclass.name, "a class can't inherit from itself")
+		}
+
+		r.currentClass = SUBCLASS_TYPE
+		r.resolveExpr(stmt.superclass)
+
+		// matches the environment the interpreter creates for "super"
+		r.beginScope()
+		r.scopes[len(r.scopes)-1]["super"] = true
+	}
+
+	// the methods are resolved inside a scope where "this" is defined
+	r.beginScope()
+	r.scopes[len(r.scopes)-1]["this"] = true
+
+	for _, method := range stmt.methods {
+		declaration := METHOD
+
+		if method.name.lexeme == "init" {
+			declaration = INITIALIZER
+		}
+
+		// print calls toString without arguments
+		if method.name.lexeme == "toString" && len(method.params) > 0 {
+			r.error(method.name, "toString can't have parameters")
+		}
+
+		r.resolveFunction(method, declaration)
+	}
+
+	r.endScope()
+
+	if stmt.superclass != nil {
+		r.endScope()
+	}
+
+	r.currentClass = enclosingClass
+
+	return nil
+}
+
+// VisitVarStmt implements IStmtVisitor.
+func (r *Resolver) VisitVarStmt(stmt *VarStmt) error {
+	r.declare(stmt.name)
+
+	if stmt.initializer != nil {
+		r.resolveExpr(stmt.initializer)
+	}
+
+	r.define(stmt.name)
+
+	return nil
+}
+
+// VisitFunctionStmt implements IStmtVisitor.
+func (r *Resolver) VisitFunctionStmt(stmt *FunctionStmt) error {
+	// the name is defined before the body is resolved, so the function can refer to itself
+	r.declare(stmt.name)
+	r.define(stmt.name)
+
+	r.resolveFunction(stmt, FUNCTION)
+
+	return nil
+}
+
+// VisitExpressionStmt implements IStmtVisitor.
+func (r *Resolver) VisitExpressionStmt(stmt *ExpressionStmt) error {
+	r.resolveExpr(stmt.expr)
+
+	return nil
+}
+
+// VisitIfStmt implements IStmtVisitor.
+func (r *Resolver) VisitIfStmt(stmt *IfStmt) error {
+	// both branches are resolved, there is no control flow in the static analysis
+	r.resolveExpr(stmt.condition)
+	r.resolveStmt(stmt.thenBranch)
+
+	if stmt.elseBranch != nil {
+		r.resolveStmt(stmt.elseBranch)
+	}
+
+	return nil
+}
+
+// VisitPrintStmt implements IStmtVisitor.
+func (r *Resolver) VisitPrintStmt(stmt *PrintStmt) error {
+	r.resolveExpr(stmt.expr)
+
+	return nil
+}
+
+// VisitReturnStmt implements IStmtVisitor.
+func (r *Resolver) VisitReturnStmt(stmt *ReturnStmt) error {
+	if r.currentFunction == NONE_FUNCTION {
+		r.error(stmt.keyword, "can't return from top-level code")
+	}
+
+	if stmt.value != nil {
+		if r.currentFunction == INITIALIZER {
+			r.error(stmt.keyword, "can't return a value from an initializer")
+		}
+
+		r.resolveExpr(stmt.value)
+	}
+
+	return nil
+}
+
+// VisitWhileStmt implements IStmtVisitor.
+func (r *Resolver) VisitWhileStmt(stmt *WhileStmt) error {
+	r.resolveExpr(stmt.condition)
+	r.resolveStmt(stmt.body)
+
+	return nil
+}
+
+// VisitForStmt implements IStmtVisitor.
+func (r *Resolver) VisitForStmt(stmt *ForStmt) error {
+	r.resolveStmt(stmt.desugared)
+
+	return nil
+}
+
+func (r *Resolver) VisitVariableExpr(expr *VariableExpr) (any, error) {
+	if len(r.scopes) > 0 {
+		if defined, ok := r.scopes[len(r.scopes)-1][expr.name.lexeme]; ok && !defined {
+			r.error(expr.name, "can't read local variable in its own initializer")
+		}
+	}
+
+	r.resolveLocal(&expr.local, expr.name)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssignExpr(expr *AssignExpr) (any, error) {
+	r.resolveExpr(expr.value)
+	r.resolveLocal(&expr.local, expr.name)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinaryExpr(expr *BinaryExpr) (any, error) {
+	r.resolveExpr(expr.left)
+	r.resolveExpr(expr.right)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitCallExpr(expr *CallExpr) (any, error) {
+	r.resolveExpr(expr.callee)
+
+	for _, argument := range expr.arguments {
+		r.resolveExpr(argument)
+	}
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitGetExpr(expr *GetExpr) (any, error) {
+	// properties are looked up dynamically, so only the object is resolved
+	r.resolveExpr(expr.object)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitSetExpr(expr *SetExpr) (any, error) {
+	r.resolveExpr(expr.value)
+	r.resolveExpr(expr.object)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitThisExpr(expr *ThisExpr) (any, error) {
+	if r.currentClass == NONE_CLASS {
+		r.error(expr.keyword, "can't use 'this' outside of a class")
+
+		return nil, nil
+	}
+
+	r.resolveLocal(&expr.local, expr.keyword)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitSuperExpr(expr *SuperExpr) (any, error) {
+	if r.currentClass == NONE_CLASS {
+		r.error(expr.keyword, "can't use 'super' outside of a class")
+
+		return nil, nil
+	}
+
+	if r.currentClass != SUBCLASS_TYPE {
+		r.error(expr.keyword, "can't use 'super' in a class with no superclass")
+
+		return nil, nil
+	}
+
+	r.resolveLocal(&expr.local, expr.keyword)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(expr *GroupingExpr) (any, error) {
+	r.resolveExpr(expr.expression)
+
+	return nil, nil
+}
+
+func (*Resolver) VisitLiteralExpr(expr *LiteralExpr) (any, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitInterpolationExpr(expr *InterpolationExpr) (any, error) {
+	r.resolveExpr(expr.desugared)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(expr *LogicalExpr) (any, error) {
+	r.resolveExpr(expr.left)
+	r.resolveExpr(expr.right)
+
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(expr *UnaryExpr) (any, error) {
+	r.resolveExpr(expr.right)
+
+	return nil, nil
+}
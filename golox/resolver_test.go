@@ -0,0 +1,132 @@
+package golox
+
+import (
+	"strings"
+	"testing"
+)
+
+func resolveSource(t *testing.T, source string) error {
+	t.Helper()
+
+	scanner := NewScanner(source)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		t.Fatalf("unexpected scan error %v", err)
+	}
+
+	parser := NewParser(tokens)
+	stmts, err := parser.parse()
+
+	if err != nil {
+		t.Fatalf("unexpected parse error %v", err)
+	}
+
+	return NewResolver().resolve(stmts)
+}
+
+func TestResolverErrors(t *testing.T) {
+	resolverTests := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{
+			name:     "own initializer",
+			source:   "var a = 1;\n{\n  var a = a;\n}",
+			expected: "error in line 3 at 'a': can't read local variable in its own initializer",
+		},
+		{
+			name:     "top-level return",
+			source:   "return 1;",
+			expected: "error in line 1 at 'return': can't return from top-level code",
+		},
+		{
+			name:     "duplicate declaration",
+			source:   "fun bad() {\n  var a = 1;\n  var a = 2;\n}",
+			expected: "error in line 3 at 'a': already a variable with this name in this scope",
+		},
+		{
+			name:     "this outside of a class",
+			source:   "fun notAMethod() {\n  print this;\n}",
+			expected: "error in line 2 at 'this': can't use 'this' outside of a class",
+		},
+		{
+			name:     "return value from initializer",
+			source:   "class Foo {\n  init() {\n    return 1;\n  }\n}",
+			expected: "error in line 3 at 'return': can't return a value from an initializer",
+		},
+		{
+			name:     "toString with parameters",
+			source:   "class Foo {\n  toString(x) {\n    return x;\n  }\n}",
+			expected: "error in line 2 at 'toString': toString can't have parameters",
+		},
+		{
+			name:     "inherit from itself",
+			source:   "class Oops < Oops {}",
+			expected: "error in line 1 at 'Oops': a class can't inherit from itself",
+		},
+		{
+			name:     "super outside of a class",
+			source:   "super.method();",
+			expected: "error in line 1 at 'super': can't use 'super' outside of a class",
+		},
+		{
+			name:     "super without a superclass",
+			source:   "class Base {\n  method() {\n    super.method();\n  }\n}",
+			expected: "error in line 3 at 'super': can't use 'super' in a class with no superclass",
+		},
+	}
+
+	for _, tt := range resolverTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := resolveSource(t, tt.source)
+
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("got %v, expected %v", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolverAllowsGlobalRedeclaration(t *testing.T) {
+	err := resolveSource(t, "var a = 1;\nvar a = a;")
+
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// the depths are kept in the syntax tree, the interpreter doesn't hold on to the expressions
+func TestResolverStoresDepths(t *testing.T) {
+	a := NewToken(IDENTIFIER, "a", "a", 1)
+	read := NewVariableExpr(a)
+	assign := NewAssignExpr(a, NewLiteralExpr(1.0))
+	global := NewVariableExpr(NewToken(IDENTIFIER, "b", "b", 1))
+
+	// { var a; { a; a = 1; b; } }
+	block := NewBlockStmt([]IStmt{
+		NewVarStmt(a, nil),
+		NewBlockStmt([]IStmt{
+			NewExpressionStmt(read),
+			NewExpressionStmt(assign),
+			NewExpressionStmt(global),
+		}),
+	})
+
+	if err := NewResolver().resolve([]IStmt{block}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !read.resolved || read.depth != 1 {
+		t.Errorf("got %+v, expected the variable to be resolved one scope up", read.local)
+	}
+
+	if !assign.resolved || assign.depth != 1 {
+		t.Errorf("got %+v, expected the assignment to be resolved one scope up", assign.local)
+	}
+
+	if global.resolved {
+		t.Errorf("expected the global variable not to be resolved")
+	}
+}
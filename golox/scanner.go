@@ -1,11 +1,13 @@
 package golox
 
 import (
-	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-
 var Keywords = map[string]TokenType{
 	"and":    AND,
 	"class":  CLASS,
@@ -25,28 +27,57 @@ var Keywords = map[string]TokenType{
 	"while":  WHILE,
 }
 
-
 type Scanner struct {
 	source  string
 	tokens  []Token
 	start   int
 	current int
 	line    int
+	// lineStart is the offset of the first character of the current line
+	lineStart int
+	// the position where the current token starts, tokens like strings can span multiple lines
+	startLine   int
+	startColumn int
+	// keepComments emits the comments as COMMENT tokens instead of discarding them
+	keepComments bool
+	// integers makes the integer literals int64 values, otherwise all the numbers are float64
+	integers bool
+	// interpolations are the strings waiting for the } that ends their ${ expression, innermost last
+	interpolations []interpolation
+	// origin is shared by all the tokens
+	origin *origin
+}
+
+type interpolation struct {
+	// the position of the opening quote, for the unterminated string error
+	start  int
+	line   int
+	column int
+	// braces counts the { inside the expression, so a block or a nested ${ doesn't end it
+	braces int
 }
 
 func NewScanner(s string) Scanner {
 	return Scanner{
-		source:  s,
-		tokens:  make([]Token, 0),
-		start:   0,
-		current: 0,
-		line:    1,
+		source:    s,
+		tokens:    make([]Token, 0),
+		start:     0,
+		current:   0,
+		line:      1,
+		lineStart: 0,
+		origin:    &origin{source: s},
 	}
 }
 
 func (s *Scanner) ScanTokens() ([]Token, error) {
+	if !utf8.ValidString(s.source) {
+		return nil, s.invalidEncoding()
+	}
+
 	for !s.isAtEnd() {
 		s.start = s.current
+		s.startLine = s.line
+		s.startColumn = s.column()
 		err := s.scanToken()
 
 		if err != nil {
@@ -54,7 +85,18 @@ func (s *Scanner) ScanTokens() ([]Token, error) {
 		}
 	}
 
-	s.tokens = append(s.tokens, NewToken(EOF, "", nil, s.line))
+	if len(s.interpolations) > 0 {
+		return nil, s.unterminatedString(s.interpolations[len(s.interpolations)-1])
+	}
+
+	s.tokens = append(s.tokens, Token{
+		tokenType: EOF,
+		line:      s.line,
+		column:    s.column(),
+		start:     s.current,
+		end:       s.current,
+		origin:    s.origin,
+	})
 
 	return s.tokens, nil
 }
@@ -67,8 +109,26 @@ func (s *Scanner) scanToken() error {
 	case ')':
 		s.addToken(RIGHT_PAREN)
 	case '{':
+		if len(s.interpolations) > 0 {
+			s.interpolations[len(s.interpolations)-1].braces++
+		}
+
 		s.addToken(LEFT_BRACE)
 	case '}':
+		if len(s.interpolations) > 0 {
+			last := len(s.interpolations) - 1
+
+			// the } that ends an interpolated expression continues the string
+			if s.interpolations[last].braces == 0 {
+				open := s.interpolations[last]
+				s.interpolations = s.interpolations[:last]
+
+				return s.string(open)
+			}
+
+			s.interpolations[last].braces--
+		}
+
 		s.addToken(RIGHT_BRACE)
 	case ',':
 		s.addToken(COMMA)
@@ -82,6 +142,8 @@ func (s *Scanner) scanToken() error {
 		s.addToken(SEMICOLON)
 	case '*':
 		s.addToken(STAR)
+	case '%':
+		s.addToken(PERCENT)
 
 	// check for the second characters
 	case '!':
@@ -114,31 +176,41 @@ func (s *Scanner) scanToken() error {
 			for s.peek() != '\n' && !s.isAtEnd() {
 				s.advance()
 			}
+
+			if s.keepComments {
+				s.addToken(COMMENT)
+			}
+		} else if s.match('*') {
+			err := s.blockComment()
+
+			if err != nil {
+				return err
+			}
 		} else {
 			s.addToken(SLASH)
 		}
-		case ' ', '\r', '\t':
-			// ignore whitespace
-		case '\n':
-			s.line++
-		case '"':
-			err := s.string()
+	case ' ', '\r', '\t':
+		// ignore whitespace
+	case '\n':
+		s.newLine()
+	case '"':
+		err := s.string(interpolation{start: s.start, line: s.startLine, column: s.startColumn})
+
+		if err != nil {
+			return err
+		}
+	default:
+		if isDigit(char) {
+			err := s.number()
 
 			if err != nil {
 				return err
 			}
-		default:
-			if isDigit(char) {
-				err := s.number()
-
-				if err != nil {
-					return err
-				}
-			} else if isAlpha(char) {
-				s.identifier()
-			} else {
-				return errors.New("unexpected character")
-			}
+		} else if isAlpha(char) {
+			s.identifier()
+		} else {
+			return s.error(fmt.Sprintf("unexpected character '%c'", char))
+		}
 	}
 
 	return nil
@@ -150,95 +222,416 @@ func (s *Scanner) addToken(tokenType TokenType) {
 }
 
 func (s *Scanner) addTokenWithLiteral(tokenType TokenType, lexeme string, literal any) {
-	s.tokens = append(s.tokens, NewToken(tokenType, lexeme, literal, s.line))
+	s.tokens = append(s.tokens, Token{
+		tokenType: tokenType,
+		lexeme:    lexeme,
+		literal:   literal,
+		line:      s.startLine,
+		column:    s.startColumn,
+		start:     s.start,
+		end:       s.current,
+		origin:    s.origin,
+	})
+}
+
+// error reports a problem with the current token, from its start to the current character
+func (s *Scanner) error(message string) error {
+	return NewScanError(Diagnostic{
+		Line:     s.startLine,
+		Column:   s.startColumn,
+		Start:    s.start,
+		End:      s.current,
+		Severity: ERROR_SEVERITY,
+		Message:  message,
+	})
+}
+
+// errorAt reports a problem inside the current token, from the start offset on the current line
+// to the current character, eg. an invalid escape sequence in a string
+func (s *Scanner) errorAt(start int, message string) error {
+	return NewScanError(Diagnostic{
+		Line:     s.line,
+		Column:   s.columnAt(start),
+		Start:    start,
+		End:      s.current,
+		Severity: ERROR_SEVERITY,
+		Message:  message,
+	})
+}
+
+// invalidEncoding reports the first byte that isn't a part of a valid UTF-8 character
+func (s *Scanner) invalidEncoding() error {
+	for offset, char := range s.source {
+		if _, size := utf8.DecodeRuneInString(s.source[offset:]); char == utf8.RuneError && size == 1 {
+			s.line = 1 + strings.Count(s.source[:offset], "\n")
+			s.lineStart = strings.LastIndex(s.source[:offset], "\n") + 1
+			s.current = offset + 1
+
+			return s.errorAt(offset, "invalid UTF-8 encoding")
+		}
+	}
+
+	return nil
+}
+
+// incompleteError is reported when the source ends before the token is complete
+func (s *Scanner) incompleteError(message string) error {
+	err := s.error(message).(*ScanError)
+	err.incomplete = true
+
+	return err
+}
+
+// newLine must be called right after a new line character is consumed
+func (s *Scanner) newLine() {
+	s.line++
+	s.lineStart = s.current
+}
+
+// column counts the characters, not the bytes, so it's right for the multi-byte characters too
+func (s *Scanner) column() int {
+	return s.columnAt(s.current)
 }
 
-func (s *Scanner) advance() byte {
-	s.current++
-	return s.source[s.current-1]
+func (s *Scanner) columnAt(offset int) int {
+	return utf8.RuneCountInString(s.source[s.lineStart:offset]) + 1
+}
+
+// the scanner reads the source as UTF-8 encoded characters, the offsets are still in bytes
+func (s *Scanner) advance() rune {
+	char, size := utf8.DecodeRuneInString(s.source[s.current:])
+	s.current += size
+
+	return char
 }
 
 /* match is like a conditional advance - it consumes the next char only if matches the expected value */
-func (s *Scanner) match(expected byte) bool {
+func (s *Scanner) match(expected rune) bool {
 	if s.isAtEnd() {
 		return false
 	}
 
-	if s.source[s.current] != expected {
+	if s.peek() != expected {
 		return false
 	}
 
-	s.current++
+	s.advance()
 	return true
 }
 
-func (s *Scanner) peek() byte {
+func (s *Scanner) peek() rune {
 	if s.isAtEnd() {
 		return 0
 	}
 
-	return s.source[s.current]
+	char, _ := utf8.DecodeRuneInString(s.source[s.current:])
+
+	return char
 }
 
-func (s *Scanner) peekNext() byte {
-	if s.current+1 >= len(s.source) {
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+
+	_, size := utf8.DecodeRuneInString(s.source[s.current:])
+
+	if s.current+size >= len(s.source) {
 		return 0
 	}
 
-	return s.source[s.current+1]
+	char, _ := utf8.DecodeRuneInString(s.source[s.current+size:])
+
+	return char
 }
 
-func (s *Scanner) string() error {
-	for s.peek() != '"' && !s.isAtEnd() {
-		if s.peek() == '\n' {
-			s.line++
+// blockComment consumes a /* ... */ comment, the comments can nest, eg. /* a /* b */ c */
+func (s *Scanner) blockComment() error {
+	depth := 1
+
+	for depth > 0 {
+		if s.isAtEnd() {
+			// the error points to the line where the comment started
+			return s.incompleteError("unterminated block comment")
 		}
 
-		s.advance()
+		char := s.advance()
+
+		switch {
+		case char == '/' && s.peek() == '*':
+			s.advance()
+			depth++
+		case char == '*' && s.peek() == '/':
+			s.advance()
+			depth--
+		case char == '\n':
+			s.newLine()
+		}
+	}
+
+	if s.keepComments {
+		s.addToken(COMMENT)
+	}
+
+	return nil
+}
+
+// string scans the rest of a string literal, either from the opening quote or from the } that
+// ends an interpolated expression. A ${ ends the current part with an INTERPOLATION token, the
+// scanner then returns to the normal tokens until the matching }.
+func (s *Scanner) string(open interpolation) error {
+	var value strings.Builder
+
+	for s.peek() != '"' && !s.isAtEnd() {
+		char := s.advance()
+
+		switch {
+		case char == '\n':
+			s.newLine()
+			value.WriteRune(char)
+		case char == '\\':
+			err := s.escape(&value)
+
+			if err != nil {
+				return err
+			}
+		case char == '$' && s.peek() == '{':
+			s.advance()
+			s.addTokenWithLiteral(INTERPOLATION, value.String(), value.String())
+			s.interpolations = append(s.interpolations, open)
+
+			return nil
+		default:
+			value.WriteRune(char)
+		}
 	}
 
 	if s.isAtEnd() {
-		return errors.New("unterminated string")
+		return s.unterminatedString(open)
 	}
 
 	// the closing "
 	s.advance()
 
-	// trim the surrounding quotes
-	value := s.source[s.start+1 : s.current-1]
+	s.addTokenWithLiteral(STRING, value.String(), value.String())
 
-	s.addTokenWithLiteral(STRING, value, value)
+	return nil
+}
+
+// unterminatedString reports the error at the opening quote, even if the string was interrupted by a ${
+func (s *Scanner) unterminatedString(open interpolation) error {
+	s.start = open.start
+	s.startLine = open.line
+	s.startColumn = open.column
+
+	return s.incompleteError("unterminated string")
+}
+
+// escape decodes the escape sequence after a backslash
+func (s *Scanner) escape(value *strings.Builder) error {
+	start := s.current - 1
+
+	// the missing closing quote is reported by the string
+	if s.isAtEnd() {
+		return nil
+	}
+
+	char := s.advance()
+
+	switch char {
+	case 'n':
+		value.WriteByte('\n')
+	case 't':
+		value.WriteByte('\t')
+	case 'r':
+		value.WriteByte('\r')
+	case '"', '\\', '$':
+		value.WriteRune(char)
+	case 'u':
+		return s.unicodeEscape(value, start)
+	default:
+		return s.errorAt(start, fmt.Sprintf("invalid escape sequence '\\%c'", char))
+	}
+
+	return nil
+}
+
+// unicodeEscape decodes a code point written as \u{XXXX}, with 1 to 6 hex digits
+func (s *Scanner) unicodeEscape(value *strings.Builder, start int) error {
+	if !s.match('{') {
+		return s.errorAt(start, "invalid unicode escape, expected \\u{XXXX}")
+	}
+
+	digitsStart := s.current
+
+	for isHexDigit(s.peek()) {
+		s.advance()
+	}
+
+	digits := s.source[digitsStart:s.current]
+
+	if len(digits) == 0 || len(digits) > 6 || !s.match('}') {
+		return s.errorAt(start, "invalid unicode escape, expected \\u{XXXX}")
+	}
+
+	codePoint, _ := strconv.ParseUint(digits, 16, 32)
+
+	if !utf8.ValidRune(rune(codePoint)) {
+		return s.errorAt(start, fmt.Sprintf("invalid unicode code point U+%s", strings.ToUpper(digits)))
+	}
+
+	value.WriteRune(rune(codePoint))
 
 	return nil
 }
 
+// number scans the decimal numbers, eg. 42, 1_000_000, 3.14 and 6.02e23, and the integers
+// with a base prefix, eg. 0xFF, 0b1010 and 0o755. The underscores can separate the digits.
 func (s *Scanner) number() error {
-	for isDigit(s.peek()) {
+	base := 10
+
+	if s.source[s.start] == '0' {
+		switch s.peek() {
+		case 'x', 'X':
+			base = 16
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		}
+	}
+
+	if base != 10 {
+		// the prefix
 		s.advance()
+
+		return s.integer(base)
 	}
 
+	isFloat := false
+	isDigitOrUnderscore := func(char rune) bool { return isDigit(char) || char == '_' }
+
+	s.consumeWhile(isDigitOrUnderscore)
+
 	if s.peek() == '.' && isDigit(s.peekNext()) {
+		isFloat = true
+
 		// consume the "."
+		s.advance()
+		s.consumeWhile(isDigitOrUnderscore)
+	}
+
+	if s.peek() == 'e' || s.peek() == 'E' {
+		isFloat = true
+
 		s.advance()
 
-		for isDigit(s.peek()) {
+		if s.peek() == '+' || s.peek() == '-' {
 			s.advance()
 		}
+
+		s.consumeWhile(isDigitOrUnderscore)
 	}
 
-	stringVal := s.source[s.start:s.current]
+	// the letters right after the number are a part of it, eg. 12ab is one invalid number
+	invalid := isAlphaNumeric(s.peek())
+	s.consumeWhile(isAlphaNumeric)
 
-	value, err := strconv.ParseFloat(stringVal, 32)
+	text := s.source[s.start:s.current]
+	digits := strings.ReplaceAll(text, "_", "")
+
+	if invalid || !validUnderscores(text, isDigit) || !isDigit(rune(digits[len(digits)-1])) {
+		return s.error(fmt.Sprintf("invalid number '%s'", text))
+	}
+
+	if s.integers && !isFloat {
+		return s.addInteger(text, digits, 10)
+	}
+
+	value, err := strconv.ParseFloat(digits, 64)
 
 	if err != nil {
-		return err
+		return s.error(fmt.Sprintf("number '%s' is out of range", text))
 	}
 
-	s.addTokenWithLiteral(NUMBER, stringVal, value)
+	s.addTokenWithLiteral(NUMBER, text, value)
 
 	return nil
 }
 
+// integer scans the digits after a base prefix
+func (s *Scanner) integer(base int) error {
+	isBaseDigit := func(char rune) bool {
+		value, err := strconv.ParseUint(string(char), 16, 8)
+
+		return err == nil && int(value) < base
+	}
+
+	s.consumeWhile(isAlphaNumeric)
+
+	text := s.source[s.start:s.current]
+	digits := strings.ReplaceAll(text[2:], "_", "")
+
+	for _, char := range digits {
+		if !isBaseDigit(char) {
+			return s.error(fmt.Sprintf("invalid digit '%c' in number '%s'", char, text))
+		}
+	}
+
+	if digits == "" || !validUnderscores(text[2:], isBaseDigit) {
+		return s.error(fmt.Sprintf("invalid number '%s'", text))
+	}
+
+	if s.integers {
+		return s.addInteger(text, digits, base)
+	}
+
+	value, err := strconv.ParseUint(digits, base, 64)
+
+	if err != nil {
+		return s.error(fmt.Sprintf("number '%s' is out of range", text))
+	}
+
+	s.addTokenWithLiteral(NUMBER, text, float64(value))
+
+	return nil
+}
+
+func (s *Scanner) addInteger(text string, digits string, base int) error {
+	value, err := strconv.ParseInt(digits, base, 64)
+
+	if err != nil {
+		return s.error(fmt.Sprintf("number '%s' is out of range", text))
+	}
+
+	s.addTokenWithLiteral(NUMBER, text, value)
+
+	return nil
+}
+
+func (s *Scanner) consumeWhile(accept func(char rune) bool) {
+	for !s.isAtEnd() && accept(s.peek()) {
+		s.advance()
+	}
+}
+
+// validUnderscores checks that every underscore is between two digits, eg. 1_000 but not 1__0 or 1_
+func validUnderscores(text string, isDigit func(char rune) bool) bool {
+	chars := []rune(text)
+
+	for i, char := range chars {
+		if char != '_' {
+			continue
+		}
+
+		if i == 0 || i == len(chars)-1 || !isDigit(chars[i-1]) || !isDigit(chars[i+1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (s *Scanner) identifier() {
 	for isAlphaNumeric(s.peek()) {
 		s.advance()
@@ -258,14 +651,21 @@ func (s *Scanner) isAtEnd() bool {
 	return s.current >= len(s.source)
 }
 
-func isDigit(char byte) bool {
+// the numbers are always written with the ASCII digits
+func isDigit(char rune) bool {
 	return char >= '0' && char <= '9'
 }
 
-func isAlpha(char byte) bool {
-	return (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_'
+func isHexDigit(char rune) bool {
+	return isDigit(char) || (char >= 'a' && char <= 'f') || (char >= 'A' && char <= 'F')
+}
+
+// isAlpha allows the identifiers to start with a letter of any language, eg. größe or имя
+func isAlpha(char rune) bool {
+	return char == '_' || unicode.IsLetter(char)
 }
 
-func isAlphaNumeric(char byte) bool {
-	return isDigit(char) || isAlpha(char)
+// isAlphaNumeric also accepts the digits and the combining marks, which some scripts need, eg. नमस्ते
+func isAlphaNumeric(char rune) bool {
+	return isAlpha(char) || unicode.IsDigit(char) || unicode.In(char, unicode.Mn, unicode.Mc)
 }
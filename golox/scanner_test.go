@@ -0,0 +1,289 @@
+package golox
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBlockComments(t *testing.T) {
+	commentTests := []struct {
+		name   string
+		source string
+		// the types and the lines of the tokens, without EOF
+		types []TokenType
+		lines []int
+	}{
+		{
+			name:   "inline",
+			source: "1 /* comment */ + 2",
+			types:  []TokenType{NUMBER, PLUS, NUMBER},
+			lines:  []int{1, 1, 1},
+		},
+		{
+			name:   "multiple lines",
+			source: "/* license\n * header\n */\nprint 1;",
+			types:  []TokenType{PRINT, NUMBER, SEMICOLON},
+			lines:  []int{4, 4, 4},
+		},
+		{
+			name:   "nested",
+			source: "/* outer /* inner\n */ still a comment */ a",
+			types:  []TokenType{IDENTIFIER},
+			lines:  []int{2},
+		},
+		{
+			name:   "line comment inside",
+			source: "/* // */ a / b",
+			types:  []TokenType{IDENTIFIER, SLASH, IDENTIFIER},
+			lines:  []int{1, 1, 1},
+		},
+	}
+
+	for _, tt := range commentTests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(tt.source)
+			tokens, err := scanner.ScanTokens()
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			tokens = tokens[:len(tokens)-1]
+
+			if len(tokens) != len(tt.types) {
+				t.Fatalf("got %d tokens %v, expected %d", len(tokens), tokens, len(tt.types))
+			}
+
+			for i, token := range tokens {
+				if token.tokenType != tt.types[i] || token.line != tt.lines[i] {
+					t.Errorf("got %s in line %d, expected %s in line %d", token.tokenType, token.line, tt.types[i], tt.lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	scanner := NewScanner("print 1;\n/* outer\n/* inner */\nprint 2;")
+	_, err := scanner.ScanTokens()
+
+	var scanErr *ScanError
+
+	if !errors.As(err, &scanErr) {
+		t.Fatalf("got %v, expected a ScanError", err)
+	}
+
+	if diagnostic := scanErr.Diagnostic(); diagnostic.Line != 2 || diagnostic.Message != "unterminated block comment" {
+		t.Errorf("got %s, expected the error in line 2", diagnostic)
+	}
+
+	if !isIncomplete("/* a\n") {
+		t.Errorf("expected the REPL to wait for the end of the comment")
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	escapeTests := []struct {
+		source   string
+		expected string
+	}{
+		{source: `"a\nb"`, expected: "a\nb"},
+		{source: `"\t\r"`, expected: "\t\r"},
+		{source: `"say \"hi\""`, expected: `say "hi"`},
+		{source: `"c:\\dir"`, expected: `c:\dir`},
+		{source: `"\u{48}\u{e9}\u{1F600}"`, expected: "H\u00e9\U0001F600"},
+		{source: `"\${x}"`, expected: "${x}"},
+	}
+
+	for _, tt := range escapeTests {
+		t.Run(tt.source, func(t *testing.T) {
+			scanner := NewScanner(tt.source)
+			tokens, err := scanner.ScanTokens()
+
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if tokens[0].tokenType != STRING || tokens[0].literal != tt.expected {
+				t.Errorf("got %s %q, expected STRING %q", tokens[0].tokenType, tokens[0].literal, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	errorTests := []struct {
+		source  string
+		message string
+		column  int
+	}{
+		{source: `print "a\qb";`, message: `invalid escape sequence '\q'`, column: 9},
+		{source: `print "\u0041";`, message: `invalid unicode escape, expected \u{XXXX}`, column: 8},
+		{source: `print "\u{}";`, message: `invalid unicode escape, expected \u{XXXX}`, column: 8},
+		{source: `print "\u{110000}";`, message: "invalid unicode code point U+110000", column: 8},
+		{source: `print "a ${b`, message: "unterminated string", column: 7},
+		{source: `print "a ${b} c`, message: "unterminated string", column: 7},
+	}
+
+	for _, tt := range errorTests {
+		t.Run(tt.source, func(t *testing.T) {
+			scanner := NewScanner(tt.source)
+			_, err := scanner.ScanTokens()
+
+			diagnostics := Diagnostics(err)
+
+			if len(diagnostics) != 1 {
+				t.Fatalf("got %v, expected one error", err)
+			}
+
+			if diagnostics[0].Message != tt.message || diagnostics[0].Column != tt.column {
+				t.Errorf("got %q at column %d, expected %q at column %d", diagnostics[0].Message, diagnostics[0].Column, tt.message, tt.column)
+			}
+		})
+	}
+}
+
+func TestInterpolationTokens(t *testing.T) {
+	scanner := NewScanner(`"a ${b} c ${ {} } d"`)
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []TokenType{INTERPOLATION, IDENTIFIER, INTERPOLATION, LEFT_BRACE, RIGHT_BRACE, STRING, EOF}
+
+	if len(tokens) != len(expected) {
+		t.Fatalf("got %v, expected %v", tokens, expected)
+	}
+
+	for i, token := range tokens {
+		if token.tokenType != expected[i] {
+			t.Errorf("got %s at %d, expected %s", token.tokenType, i, expected[i])
+		}
+	}
+}
+
+func TestUnicodeSource(t *testing.T) {
+	scanner := NewScanner("var größe = \"日本語\";\nprint имя_2 + नमस्ते;")
+	tokens, err := scanner.ScanTokens()
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []struct {
+		tokenType TokenType
+		lexeme    string
+		line      int
+		column    int
+	}{
+		{VAR, "var", 1, 1},
+		{IDENTIFIER, "größe", 1, 5},
+		{EQUAL, "=", 1, 11},
+		{STRING, "日本語", 1, 13},
+		{SEMICOLON, ";", 1, 18},
+		{PRINT, "print", 2, 1},
+		{IDENTIFIER, "имя_2", 2, 7},
+		{PLUS, "+", 2, 13},
+		{IDENTIFIER, "नमस्ते", 2, 15},
+		{SEMICOLON, ";", 2, 21},
+	}
+
+	for i, want := range expected {
+		got := tokens[i]
+
+		if got.tokenType != want.tokenType || got.lexeme != want.lexeme || got.line != want.line || got.column != want.column {
+			t.Errorf("got %s %q at %d:%d, expected %s %q at %d:%d", got.tokenType, got.lexeme, got.line, got.column, want.tokenType, want.lexeme, want.line, want.column)
+		}
+	}
+}
+
+func TestInvalidEncoding(t *testing.T) {
+	scanner := NewScanner("print 1;\nprint \"a\xffb\";")
+	_, err := scanner.ScanTokens()
+
+	diagnostics := Diagnostics(err)
+
+	if len(diagnostics) != 1 || diagnostics[0].Line != 2 || diagnostics[0].Column != 9 || diagnostics[0].Start != 17 {
+		t.Errorf("got %v, expected an invalid encoding error at 2:9", diagnostics)
+	}
+}
+
+func TestNumberLiterals(t *testing.T) {
+	numberTests := []struct {
+		source  string
+		float   float64
+		integer any
+	}{
+		{source: "42", float: 42, integer: int64(42)},
+		{source: "1_000_000", float: 1e6, integer: int64(1000000)},
+		{source: "0xFF", float: 255, integer: int64(255)},
+		{source: "0xdead_beef", float: 0xdeadbeef, integer: int64(0xdeadbeef)},
+		{source: "0b1010", float: 10, integer: int64(10)},
+		{source: "0o755", float: 0o755, integer: int64(0o755)},
+		{source: "0.1", float: 0.1, integer: 0.1},
+		{source: "6.02e23", float: 6.02e23, integer: 6.02e23},
+		{source: "2.5E-3", float: 2.5e-3, integer: 2.5e-3},
+		{source: "1e3", float: 1000, integer: 1000.0},
+		{source: "9007199254740993", float: 9007199254740992, integer: int64(9007199254740993)},
+	}
+
+	for _, tt := range numberTests {
+		t.Run(tt.source, func(t *testing.T) {
+			scanner := NewScanner(tt.source)
+			tokens, err := scanner.ScanTokens()
+
+			if err != nil || tokens[0].literal != tt.float || tokens[0].lexeme != tt.source {
+				t.Errorf("got %v %v, expected %v", tokens, err, tt.float)
+			}
+
+			scanner = NewScanner(tt.source)
+			scanner.integers = true
+			tokens, err = scanner.ScanTokens()
+
+			if err != nil || tokens[0].literal != tt.integer {
+				t.Errorf("with integers got %v %v, expected %v", tokens, err, tt.integer)
+			}
+		})
+	}
+}
+
+func TestInvalidNumbers(t *testing.T) {
+	invalidTests := []struct {
+		source  string
+		message string
+	}{
+		{source: "12ab", message: "invalid number '12ab'"},
+		{source: "1__0", message: "invalid number '1__0'"},
+		{source: "1_", message: "invalid number '1_'"},
+		{source: "1_.5", message: "invalid number '1_.5'"},
+		{source: "1e", message: "invalid number '1e'"},
+		{source: "1e+", message: "invalid number '1e+'"},
+		{source: "0x", message: "invalid number '0x'"},
+		{source: "0x_1", message: "invalid number '0x_1'"},
+		{source: "0b102", message: "invalid digit '2' in number '0b102'"},
+		{source: "0o8", message: "invalid digit '8' in number '0o8'"},
+		{source: "1e400", message: "number '1e400' is out of range"},
+	}
+
+	for _, tt := range invalidTests {
+		t.Run(tt.source, func(t *testing.T) {
+			scanner := NewScanner(tt.source)
+			_, err := scanner.ScanTokens()
+
+			diagnostics := Diagnostics(err)
+
+			if len(diagnostics) != 1 || diagnostics[0].Message != tt.message {
+				t.Errorf("got %v, expected %q", err, tt.message)
+			}
+		})
+	}
+
+	scanner := NewScanner("9223372036854775808")
+	scanner.integers = true
+
+	if _, err := scanner.ScanTokens(); err == nil {
+		t.Errorf("expected the integer to be out of range")
+	}
+}
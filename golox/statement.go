@@ -1,8 +1,16 @@
 package golox
 
 type IStmtVisitor interface {
-	VisitExpressionStmt(stmt ExpressionStmt) error
-	VisitPrintStmt(stmt PrintStmt) error
+	VisitExpressionStmt(stmt *ExpressionStmt) error
+	VisitPrintStmt(stmt *PrintStmt) error
+	VisitVarStmt(stmt *VarStmt) error
+	VisitBlockStmt(stmt *BlockStmt) error
+	VisitIfStmt(stmt *IfStmt) error
+	VisitWhileStmt(stmt *WhileStmt) error
+	VisitForStmt(stmt *ForStmt) error
+	VisitFunctionStmt(stmt *FunctionStmt) error
+	VisitReturnStmt(stmt *ReturnStmt) error
+	VisitClassStmt(stmt *ClassStmt) error
 }
 
 type IStmt interface {
@@ -13,11 +21,11 @@ type ExpressionStmt struct {
 	expr IExpr
 }
 
-func NewExpressionStmt(expr IExpr) ExpressionStmt {
-	return ExpressionStmt{expr}
+func NewExpressionStmt(expr IExpr) *ExpressionStmt {
+	return &ExpressionStmt{expr}
 }
 
-func (s ExpressionStmt) Accept(v IStmtVisitor) error {
+func (s *ExpressionStmt) Accept(v IStmtVisitor) error {
 	return v.VisitExpressionStmt(s)
 }
 
@@ -25,10 +33,122 @@ type PrintStmt struct {
 	expr IExpr
 }
 
-func NewPrintStmt(expr IExpr) PrintStmt {
-	return PrintStmt{expr}
+func NewPrintStmt(expr IExpr) *PrintStmt {
+	return &PrintStmt{expr}
 }
 
-func (p PrintStmt) Accept(v IStmtVisitor) error {
+func (p *PrintStmt) Accept(v IStmtVisitor) error {
 	return v.VisitPrintStmt(p)
 }
+
+type VarStmt struct {
+	name        Token
+	initializer IExpr
+}
+
+func NewVarStmt(name Token, initializer IExpr) *VarStmt {
+	return &VarStmt{name, initializer}
+}
+
+func (s *VarStmt) Accept(v IStmtVisitor) error {
+	return v.VisitVarStmt(s)
+}
+
+type BlockStmt struct {
+	statements []IStmt
+}
+
+func NewBlockStmt(statements []IStmt) *BlockStmt {
+	return &BlockStmt{statements}
+}
+
+func (s *BlockStmt) Accept(v IStmtVisitor) error {
+	return v.VisitBlockStmt(s)
+}
+
+type IfStmt struct {
+	condition  IExpr
+	thenBranch IStmt
+	elseBranch IStmt
+}
+
+func NewIfStmt(condition IExpr, thenBranch IStmt, elseBranch IStmt) *IfStmt {
+	return &IfStmt{condition, thenBranch, elseBranch}
+}
+
+func (s *IfStmt) Accept(v IStmtVisitor) error {
+	return v.VisitIfStmt(s)
+}
+
+type WhileStmt struct {
+	condition IExpr
+	body      IStmt
+}
+
+func NewWhileStmt(condition IExpr, body IStmt) *WhileStmt {
+	return &WhileStmt{condition, body}
+}
+
+func (s *WhileStmt) Accept(v IStmtVisitor) error {
+	return v.VisitWhileStmt(s)
+}
+
+// ForStmt keeps the clauses of a for loop as they were written, the interpreter and
+// the resolver only see the desugared while loop
+type ForStmt struct {
+	initializer IStmt
+	condition   IExpr
+	increment   IExpr
+	body        IStmt
+	desugared   IStmt
+}
+
+func NewForStmt(initializer IStmt, condition IExpr, increment IExpr, body IStmt, desugared IStmt) *ForStmt {
+	return &ForStmt{initializer, condition, increment, body, desugared}
+}
+
+func (s *ForStmt) Accept(v IStmtVisitor) error {
+	return v.VisitForStmt(s)
+}
+
+type FunctionStmt struct {
+	name   Token
+	params []Token
+	body   []IStmt
+}
+
+func NewFunctionStmt(name Token, params []Token, body []IStmt) *FunctionStmt {
+	return &FunctionStmt{name, params, body}
+}
+
+func (s *FunctionStmt) Accept(v IStmtVisitor) error {
+	return v.VisitFunctionStmt(s)
+}
+
+type ReturnStmt struct {
+	keyword Token
+	value   IExpr
+}
+
+func NewReturnStmt(keyword Token, value IExpr) *ReturnStmt {
+	return &ReturnStmt{keyword, value}
+}
+
+func (s *ReturnStmt) Accept(v IStmtVisitor) error {
+	return v.VisitReturnStmt(s)
+}
+
+type ClassStmt struct {
+	name Token
+	// superclass is nil if the class doesn't inherit another class
+	superclass *VariableExpr
+	methods    []*FunctionStmt
+}
+
+func NewClassStmt(name Token, superclass *VariableExpr, methods []*FunctionStmt) *ClassStmt {
+	return &ClassStmt{name, superclass, methods}
+}
+
+func (s *ClassStmt) Accept(v IStmtVisitor) error {
+	return v.VisitClassStmt(s)
+}
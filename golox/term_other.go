@@ -0,0 +1,15 @@
+//go:build !linux
+
+package golox
+
+import "errors"
+
+// the raw terminal mode is only implemented for linux, other systems fall back to plain line reading
+
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+func makeRaw(fd uintptr) (func() error, error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
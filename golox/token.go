@@ -17,6 +17,7 @@ const (
 	SEMICOLON
 	SLASH
 	STAR
+	PERCENT
 
 	// one or two character tokens
 	BANG
@@ -31,6 +32,8 @@ const (
 	// literals
 	IDENTIFIER
 	STRING
+	// INTERPOLATION is the part of a string before a ${, eg. "Hello ${
+	INTERPOLATION
 	NUMBER
 
 	// keywords
@@ -51,18 +54,88 @@ const (
 	VAR
 	WHILE
 
+	// COMMENT tokens are only produced when the scanner keeps the comments, eg. for the formatter
+	COMMENT
+
 	EOF
 )
 
+var tokenNames = [...]string{
+	LEFT_PAREN:    "LEFT_PAREN",
+	RIGHT_PAREN:   "RIGHT_PAREN",
+	LEFT_BRACE:    "LEFT_BRACE",
+	RIGHT_BRACE:   "RIGHT_BRACE",
+	COMMA:         "COMMA",
+	DOT:           "DOT",
+	MINUS:         "MINUS",
+	PLUS:          "PLUS",
+	SEMICOLON:     "SEMICOLON",
+	SLASH:         "SLASH",
+	STAR:          "STAR",
+	PERCENT:       "PERCENT",
+	BANG:          "BANG",
+	BANG_EQUAL:    "BANG_EQUAL",
+	EQUAL:         "EQUAL",
+	EQUAL_EQUAL:   "EQUAL_EQUAL",
+	GREATER:       "GREATER",
+	GREATER_EQUAL: "GREATER_EQUAL",
+	LESS:          "LESS",
+	LESS_EQUAL:    "LESS_EQUAL",
+	IDENTIFIER:    "IDENTIFIER",
+	STRING:        "STRING",
+	INTERPOLATION: "INTERPOLATION",
+	NUMBER:        "NUMBER",
+	AND:           "AND",
+	CLASS:         "CLASS",
+	ELSE:          "ELSE",
+	FALSE:         "FALSE",
+	FUN:           "FUN",
+	FOR:           "FOR",
+	IF:            "IF",
+	NIL:           "NIL",
+	OR:            "OR",
+	PRINT:         "PRINT",
+	RETURN:        "RETURN",
+	SUPER:         "SUPER",
+	THIS:          "THIS",
+	TRUE:          "TRUE",
+	VAR:           "VAR",
+	WHILE:         "WHILE",
+	COMMENT:       "COMMENT",
+	EOF:           "EOF",
+}
+
+func (t TokenType) String() string {
+	if int(t) < len(tokenNames) {
+		return tokenNames[t]
+	}
+
+	return fmt.Sprintf("TokenType(%d)", int(t))
+}
+
 type Token struct {
 	tokenType TokenType
 	lexeme    string
 	literal   any
-	line int
+	line      int
+	// column is the 1-based position of the token in its line
+	column int
+	// start and end are the byte offsets of the token in the source
+	start int
+	end   int
+	// origin is the source the token was scanned from
+	origin *origin
+}
+
+// origin is the source code a token comes from. A runtime error can happen long after its
+// source was compiled, eg. in a function declared by an earlier REPL input or by a loaded file.
+type origin struct {
+	file   string
+	source string
 }
 
 func NewToken(tokenType TokenType, lexeme string, literal any, line int) Token {
-	return Token{tokenType, lexeme, literal, line}
+	return Token{tokenType: tokenType, lexeme: lexeme, literal: literal, line: line}
 }
 
 func (t Token) String() string {
@@ -0,0 +1,172 @@
+package golox
+
+import (
+	"fmt"
+	"math"
+)
+
+// Value is a Lox value as the Go code sees it: nil, a bool, a float64, an int64 when the
+// integers are turned on, a string, a LoxCallable, eg. a function or a class, an instance
+// or a module of native functions
+type Value = any
+
+// ToValue converts a Go value to a Lox value. The Go integers become float64 numbers,
+// unless they are too big to be represented exactly. The Lox values are returned as they are.
+func ToValue(value any) (Value, error) {
+	return toValue(value, false)
+}
+
+// toValue converts the Go integers to int64 when the integers are turned on
+func toValue(value any, integers bool) (Value, error) {
+	var integer int64
+
+	switch value := value.(type) {
+	case nil, bool, string, float64, LoxCallable, *LoxInstance, *nativeModule:
+		return value, nil
+	case float32:
+		return float64(value), nil
+	case int64:
+		integer = value
+	case int:
+		integer = int64(value)
+	case int8:
+		integer = int64(value)
+	case int16:
+		integer = int64(value)
+	case int32:
+		integer = int64(value)
+	case uint8:
+		integer = int64(value)
+	case uint16:
+		integer = int64(value)
+	case uint32:
+		integer = int64(value)
+	case uint:
+		if uint64(value) > math.MaxInt64 {
+			return nil, fmt.Errorf("%d is too big for a Lox number", value)
+		}
+
+		integer = int64(value)
+	case uint64:
+		if value > math.MaxInt64 {
+			return nil, fmt.Errorf("%d is too big for a Lox number", value)
+		}
+
+		integer = int64(value)
+	default:
+		return nil, fmt.Errorf("can't convert %T to a Lox value", value)
+	}
+
+	if integers {
+		return integer, nil
+	}
+
+	// the floats are exact only up to 2^53
+	if integer > 1<<53 || integer < -(1<<53) {
+		return nil, fmt.Errorf("%d is too big for a Lox number", integer)
+	}
+
+	return float64(integer), nil
+}
+
+// AsNumber returns the number as a float64, both for the floats and the integers
+func AsNumber(value Value) (float64, bool) {
+	if !isNumber(value) {
+		return 0, false
+	}
+
+	return toFloat(value), true
+}
+
+// AsInt returns the integers and the floats without a fraction as an int64
+func AsInt(value Value) (int64, bool) {
+	return toInt(value)
+}
+
+// AsString returns the value if it's a string, use VM.Stringify to convert any value to a string
+func AsString(value Value) (string, bool) {
+	text, ok := value.(string)
+
+	return text, ok
+}
+
+// AsBool returns the value if it's a bool, unlike an if statement it doesn't treat nil as false
+func AsBool(value Value) (bool, bool) {
+	b, ok := value.(bool)
+
+	return b, ok
+}
+
+// ValueType is the type a native function expects for an argument
+type ValueType int
+
+const (
+	ANY_VALUE ValueType = iota
+	NUMBER_VALUE
+	// INTEGER_VALUE is a number without a fraction, eg. an index, it's passed to Go as an int64
+	INTEGER_VALUE
+	STRING_VALUE
+	BOOL_VALUE
+	CALLABLE_VALUE
+	INSTANCE_VALUE
+)
+
+var valueTypeNames = map[ValueType]string{
+	ANY_VALUE:      "any value",
+	NUMBER_VALUE:   "a number",
+	INTEGER_VALUE:  "an integer",
+	STRING_VALUE:   "a string",
+	BOOL_VALUE:     "a boolean",
+	CALLABLE_VALUE: "a function or a class",
+	INSTANCE_VALUE: "an instance",
+}
+
+func (t ValueType) String() string {
+	return valueTypeNames[t]
+}
+
+// accepts checks the type of the value, an integer is converted to an int64
+func (t ValueType) accepts(value Value) (Value, bool) {
+	switch t {
+	case NUMBER_VALUE:
+		return value, isNumber(value)
+	case INTEGER_VALUE:
+		return AsInt(value)
+	case STRING_VALUE:
+		_, ok := value.(string)
+		return value, ok
+	case BOOL_VALUE:
+		_, ok := value.(bool)
+		return value, ok
+	case CALLABLE_VALUE:
+		_, ok := value.(LoxCallable)
+		return value, ok
+	case INSTANCE_VALUE:
+		_, ok := value.(*LoxInstance)
+		return value, ok
+	}
+
+	return value, true
+}
+
+// typeName describes the type of a value in the error messages
+func typeName(value Value) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "a boolean"
+	case float64, int64:
+		return "a number"
+	case string:
+		return "a string"
+	case LoxCallable:
+		return "a function or a class"
+	case *LoxInstance:
+		return "an instance"
+	case *nativeModule:
+		return "a module"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,156 @@
+package golox
+
+import (
+	"context"
+	"fmt"
+)
+
+// VM runs Lox code for a Go program. The globals live as long as the VM, so the functions
+// and the variables one Eval defines can be used by the next Eval, by Call and by GetGlobal.
+// A VM must not be used by more than one goroutine at the same time.
+type VM struct {
+	lox *Lox
+}
+
+// NewVM creates a VM with the options, eg. the output streams or a limit of steps for every Eval and Call
+func NewVM(options Options) *VM {
+	lox := &Lox{options: options}
+	lox.interpreter = lox.newInterpreter()
+
+	return &VM{lox}
+}
+
+// Eval runs the source and returns the value of its last statement, when it's an expression,
+// otherwise nil. The last expression doesn't need a semicolon, eg. "1 + 2" returns 3.
+// The errors are returned as a ScanError, ParseErrors, RuntimeError or StepLimitError, or as
+// the context error when the context is cancelled.
+func (vm *VM) Eval(ctx context.Context, source string) (Value, error) {
+	stmts, err := vm.lox.compile("", source, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var last *ExpressionStmt
+
+	if len(stmts) > 0 {
+		if stmt, ok := stmts[len(stmts)-1].(*ExpressionStmt); ok {
+			last = stmt
+			stmts = stmts[:len(stmts)-1]
+		}
+	}
+
+	defer vm.run(ctx)()
+
+	err = vm.lox.interpreter.interpret(stmts)
+
+	if err != nil || last == nil {
+		return nil, err
+	}
+
+	return vm.lox.interpreter.evaluate(last.expr)
+}
+
+// Call calls a global function or class with the arguments, which are converted with ToValue
+func (vm *VM) Call(name string, args ...any) (Value, error) {
+	return vm.CallContext(context.Background(), name, args...)
+}
+
+// CallContext is like Call, but the function is stopped when the context is cancelled
+func (vm *VM) CallContext(ctx context.Context, name string, args ...any) (Value, error) {
+	value, ok := vm.GetGlobal(name)
+
+	if !ok {
+		return nil, fmt.Errorf("undefined function '%s'", name)
+	}
+
+	function, ok := value.(LoxCallable)
+
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a function or a class", name)
+	}
+
+	if len(args) != function.Arity() {
+		return nil, fmt.Errorf("'%s' expects %d arguments but got %d", name, function.Arity(), len(args))
+	}
+
+	arguments := make([]any, 0, len(args))
+
+	for i, arg := range args {
+		argument, err := toValue(arg, vm.lox.options.Integers)
+
+		if err != nil {
+			return nil, fmt.Errorf("argument %d of '%s': %w", i+1, name, err)
+		}
+
+		arguments = append(arguments, argument)
+	}
+
+	defer vm.run(ctx)()
+
+	// the steps are counted for each call, like for each Eval
+	vm.lox.interpreter.steps = 0
+
+	return function.Call(vm.lox.interpreter, arguments)
+}
+
+// run sets the context of the interpreter, the returned function clears it
+func (vm *VM) run(ctx context.Context) func() {
+	vm.lox.interpreter.ctx = ctx
+
+	return func() {
+		vm.lox.interpreter.ctx = nil
+	}
+}
+
+// SetGlobal defines a global variable, or changes its value, the value is converted with ToValue
+func (vm *VM) SetGlobal(name string, value any) error {
+	converted, err := toValue(value, vm.lox.options.Integers)
+
+	if err != nil {
+		return fmt.Errorf("global '%s': %w", name, err)
+	}
+
+	vm.lox.interpreter.globals.define(name, converted)
+
+	return nil
+}
+
+// GetGlobal returns the value of a global variable, ok is false if it's not defined
+func (vm *VM) GetGlobal(name string) (value Value, ok bool) {
+	value, ok = vm.lox.interpreter.globals.values[name]
+
+	return value, ok
+}
+
+// Register defines a global native function, eg. to give the scripts access to the host's data:
+//
+//	vm.Register("price", golox.Native{
+//		Params: []golox.ValueType{golox.STRING_VALUE},
+//		Fn: func(args []golox.Value) (golox.Value, error) {
+//			return prices[args[0].(string)], nil
+//		},
+//	})
+//
+// A call with the wrong number of arguments or with an argument of the wrong type is a RuntimeError.
+func (vm *VM) Register(name string, native Native) {
+	vm.lox.interpreter.globals.define(name, newNativeFunction(name, native, vm.lox.options.Integers))
+}
+
+// RegisterModule defines a global module with the native functions as its properties,
+// eg. the module "strings" with the function "upper" is called as strings.upper("a").
+// The functions of a module can't be changed by the Lox code, print shows it as <module strings>.
+func (vm *VM) RegisterModule(name string, functions map[string]Native) {
+	module := &nativeModule{name, map[string]*NativeFunction{}}
+
+	for functionName, native := range functions {
+		module.functions[functionName] = newNativeFunction(name+"."+functionName, native, vm.lox.options.Integers)
+	}
+
+	vm.lox.interpreter.globals.define(name, module)
+}
+
+// Stringify converts the value to a string the same way print does, including toString methods
+func (vm *VM) Stringify(value Value) (string, error) {
+	return vm.lox.interpreter.stringify(value)
+}
@@ -0,0 +1,290 @@
+package golox
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestVMEval(t *testing.T) {
+	var stdout strings.Builder
+
+	vm := NewVM(Options{Stdout: &stdout})
+
+	value, err := vm.Eval(context.Background(), "fun double(n) { return n * 2; }\nprint \"hi\";\ndouble(21)")
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if value != 42.0 {
+		t.Errorf("got %v, expected 42", value)
+	}
+
+	if stdout.String() != "hi\n" {
+		t.Errorf("got output %q, expected %q", stdout.String(), "hi\n")
+	}
+
+	// the globals are kept between the evals
+	value, err = vm.Eval(context.Background(), "var x = double(2);")
+
+	if err != nil || value != nil {
+		t.Errorf("got %v, %v, expected nil without an error", value, err)
+	}
+
+	if x, ok := vm.GetGlobal("x"); !ok || x != 4.0 {
+		t.Errorf("got x = %v, %v, expected 4", x, ok)
+	}
+
+	if _, err := vm.Eval(context.Background(), "print (1;"); !errors.As(err, new(ParseErrors)) {
+		t.Errorf("got %v, expected a parse error", err)
+	}
+}
+
+func TestVMEvalCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	vm := NewVM(Options{})
+
+	if _, err := vm.Eval(ctx, "while (true) {}"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, expected the context to stop the loop", err)
+	}
+
+	// the next eval isn't affected by the cancelled context
+	if value, err := vm.Eval(context.Background(), "1 + 1"); err != nil || value != 2.0 {
+		t.Errorf("got %v, %v, expected 2", value, err)
+	}
+}
+
+func TestVMCall(t *testing.T) {
+	vm := NewVM(Options{})
+
+	_, err := vm.Eval(context.Background(), "fun greet(name, times) { return \"${name} x${times}\"; }\nclass Point { init(x) { this.x = x; } }")
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	value, err := vm.Call("greet", "Lox", 3)
+
+	if err != nil || value != "Lox x3" {
+		t.Errorf("got %v, %v, expected \"Lox x3\"", value, err)
+	}
+
+	point, err := vm.Call("Point", int8(5))
+
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if text, _ := vm.Stringify(point); text != "Point instance" {
+		t.Errorf("got %q, expected a Point instance", text)
+	}
+
+	callErrors := []struct {
+		name     string
+		function string
+		args     []any
+		expected string
+	}{
+		{name: "undefined", function: "nope", expected: "undefined function 'nope'"},
+		{name: "not callable", function: "clock2", expected: "'clock2' is not a function or a class"},
+		{name: "arity", function: "greet", args: []any{"a"}, expected: "'greet' expects 2 arguments but got 1"},
+		{name: "conversion", function: "greet", args: []any{"a", []int{1}}, expected: "argument 2 of 'greet': can't convert []int to a Lox value"},
+	}
+
+	if err := vm.SetGlobal("clock2", true); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for _, tt := range callErrors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := vm.Call(tt.function, tt.args...)
+
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("got %v, expected %q", err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVMGlobals(t *testing.T) {
+	vm := NewVM(Options{})
+
+	if err := vm.SetGlobal("limit", uint16(10)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	value, err := vm.Eval(context.Background(), "limit / 4")
+
+	if err != nil || value != 2.5 {
+		t.Errorf("got %v, %v, expected 2.5", value, err)
+	}
+
+	if err := vm.SetGlobal("big", uint64(1)<<60); err == nil {
+		t.Errorf("expected an error for a number that doesn't fit a float")
+	}
+
+	if _, ok := vm.GetGlobal("missing"); ok {
+		t.Errorf("expected an undefined global to be missing")
+	}
+
+	integers := NewVM(Options{Integers: true})
+
+	if err := integers.SetGlobal("limit", 10); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	value, err = integers.Eval(context.Background(), "limit / 4")
+
+	if err != nil || value != int64(2) {
+		t.Errorf("got %v (%T), %v, expected the integer 2", value, value, err)
+	}
+}
+
+func TestValueConversions(t *testing.T) {
+	if n, ok := AsNumber(int64(3)); !ok || n != 3 {
+		t.Errorf("got %v, %v, expected 3", n, ok)
+	}
+
+	if _, ok := AsNumber("3"); ok {
+		t.Errorf("expected a string not to be a number")
+	}
+
+	if n, ok := AsInt(4.0); !ok || n != 4 {
+		t.Errorf("got %v, %v, expected 4", n, ok)
+	}
+
+	if _, ok := AsInt(4.5); ok {
+		t.Errorf("expected a fraction not to be an integer")
+	}
+
+	if s, ok := AsString("a"); !ok || s != "a" {
+		t.Errorf("got %v, %v, expected \"a\"", s, ok)
+	}
+
+	if b, ok := AsBool(nil); ok || b {
+		t.Errorf("expected nil not to be a bool")
+	}
+
+	if value, err := ToValue(float32(1.5)); err != nil || value != 1.5 {
+		t.Errorf("got %v, %v, expected 1.5", value, err)
+	}
+}
+
+func TestVMNatives(t *testing.T) {
+	vm := NewVM(Options{})
+
+	vm.Register("repeat", Native{
+		Params: []ValueType{STRING_VALUE, INTEGER_VALUE},
+		Fn: func(args []Value) (Value, error) {
+			if args[1].(int64) < 0 {
+				return nil, errors.New("negative count")
+			}
+
+			return strings.Repeat(args[0].(string), int(args[1].(int64))), nil
+		},
+	})
+
+	vm.RegisterModule("text", map[string]Native{
+		"upper": {
+			Params: []ValueType{STRING_VALUE},
+			Fn: func(args []Value) (Value, error) {
+				return strings.ToUpper(args[0].(string)), nil
+			},
+		},
+		"bad": {
+			Fn: func(args []Value) (Value, error) {
+				return make(chan int), nil
+			},
+		},
+		"length": {
+			Params: []ValueType{STRING_VALUE},
+			Fn: func(args []Value) (Value, error) {
+				return len(args[0].(string)), nil
+			},
+		},
+	})
+
+	value, err := vm.Eval(context.Background(), "text.upper(repeat(\"ab\", 2)) + text.length(\"abc\")")
+
+	if err != nil || value != "ABAB3" {
+		t.Errorf("got %v, %v, expected \"ABAB3\"", value, err)
+	}
+
+	// comparing the natives must not panic in the host
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("comparing the natives panicked: %v", r)
+			}
+		}()
+
+		value, err = vm.Eval(context.Background(), "text.upper == text.upper and repeat == repeat and text.upper != text.length")
+
+		if err != nil || value != true {
+			t.Errorf("got %v, %v, expected the natives to compare by identity", value, err)
+		}
+	}()
+
+	nativeErrors := []struct {
+		name     string
+		source   string
+		expected string
+	}{
+		{name: "arity", source: "repeat(\"a\");", expected: "error in line 1 at ')': expected 2 arguments but got 1"},
+		{name: "type", source: "\n  repeat(\"a\", \"b\");", expected: "error in line 2 at ')': argument 2 of 'repeat' must be an integer, got a string"},
+		{name: "fraction", source: "repeat(\"a\", 1.5);", expected: "error in line 1 at ')': argument 2 of 'repeat' must be an integer, got a number"},
+		{name: "module", source: "text.upper(nil);", expected: "error in line 1 at ')': argument 1 of 'text.upper' must be a string, got nil"},
+		{name: "go error", source: "repeat(\"a\", -1);", expected: "error in line 1 at ')': repeat: negative count"},
+		{name: "bad value", source: "text.bad();", expected: "error in line 1 at ')': text.bad returned a bad value, can't convert chan int to a Lox value"},
+		{name: "undefined in module", source: "text.lower(\"A\");", expected: "error in line 1 at 'lower': undefined function 'lower' in module 'text'"},
+		{name: "read-only module", source: "text.upper = nil;", expected: "error in line 1 at 'upper': can't change the functions of module 'text'"},
+	}
+
+	for _, tt := range nativeErrors {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := vm.Eval(context.Background(), tt.source)
+
+			if !errors.As(err, new(*RuntimeError)) || err.Error() != tt.expected {
+				t.Errorf("got %v, expected the runtime error %q", err, tt.expected)
+			}
+		})
+	}
+
+	// called from Go, there is no call token
+	_, err = vm.Call("repeat", "a", -1)
+
+	if nativeErr := new(NativeError); !errors.As(err, &nativeErr) || nativeErr.Message() != "repeat: negative count" {
+		t.Errorf("got %v, expected a NativeError", err)
+	}
+
+	module, _ := vm.GetGlobal("text")
+
+	if text, _ := vm.Stringify(module); text != "<module text>" {
+		t.Errorf("got %q, expected the module to print as <module text>", text)
+	}
+}
+
+func TestVMStackOverflow(t *testing.T) {
+	vm := NewVM(Options{})
+
+	_, err := vm.Eval(context.Background(), "fun f() {\n  f();\n}\nf();")
+
+	if !errors.As(err, new(*RuntimeError)) || err.Error() != "error in line 2 at ')': stack overflow" {
+		t.Errorf("got %v, expected a stack overflow at the recursive call", err)
+	}
+
+	if _, err := vm.Call("f"); err == nil || !strings.Contains(err.Error(), "stack overflow") {
+		t.Errorf("got %v, expected a stack overflow", err)
+	}
+
+	// the depth is back to zero after the errors
+	value, err := vm.Eval(context.Background(), "fun count(n) { if (n == 0) return 0; return 1 + count(n - 1); }\ncount(5000)")
+
+	if err != nil || value != 5000.0 {
+		t.Errorf("got %v, %v, expected 5000", value, err)
+	}
+}
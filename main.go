@@ -2,46 +2,391 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"sort"
+	"strings"
 
 	"github.com/tosevzoran/go-lox/golox"
 )
 
+// the exit codes follow the conventions from the UNIX sysexits.h header
+const (
+	exitUsage    = 64
+	exitDataErr  = 65
+	exitSoftware = 70
+	exitIOErr    = 74
+)
+
+// command is a golox subcommand, it gets the arguments after its name and returns the exit code
+type command struct {
+	description string
+	run         func(args []string) int
+}
+
+var commands map[string]command
+
+func init() {
+	// initialized in init, because the help command refers to the map itself
+	commands = map[string]command{
+		"run":    {"run a file or the -e code", runCommand},
+		"repl":   {"start the interactive prompt", replCommand},
+		"tokens": {"print the tokens of a file", tokensCommand},
+		"ast":    {"print the syntax tree of a file", astCommand},
+		"fmt":    {"format files", fmtCommand},
+		"check":  {"report the errors in files without running them", checkCommand},
+		"test":   {"run files and compare their output with the // expect: comments", testCommand},
+		"help":   {"show this list of commands", helpCommand},
+	}
+}
+
 func main() {
 	args := os.Args[1:]
 
-	var ioReader *bufio.Reader
-
 	if len(args) == 0 {
-		ioReader = bufio.NewReader(os.Stdin)
+		os.Exit(replCommand(nil))
+	}
+
+	if command, ok := commands[args[0]]; ok {
+		os.Exit(command.run(args[1:]))
+	}
+
+	// golox file.lox is a shortcut for golox run file.lox
+	os.Exit(runCommand(args))
+}
+
+func helpCommand([]string) int {
+	names := make([]string, 0, len(commands))
+
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	fmt.Println("usage: golox <command> [flags] [arguments]")
+	fmt.Println()
+
+	for _, name := range names {
+		fmt.Printf("  %-8s %s\n", name, commands[name].description)
+	}
+
+	fmt.Println()
+	fmt.Println("Without a command golox starts the REPL, golox file.lox is the same as golox run file.lox.")
+	fmt.Println("Run golox <command> -h to see the flags of a command.")
+
+	return 0
+}
+
+// sessionFlags are the flags shared by the commands that run code
+type sessionFlags struct {
+	trace    bool
+	maxSteps int
+	integers bool
+}
+
+func addSessionFlags(flags *flag.FlagSet) *sessionFlags {
+	session := &sessionFlags{}
+
+	flags.BoolVar(&session.trace, "trace", false, "print every statement to stderr before it's executed")
+	flags.IntVar(&session.maxSteps, "max-steps", 0, "stop the program after that many statements, 0 means no limit")
+	flags.BoolVar(&session.integers, "integers", false, "make the integer literals integers, with integer division")
+
+	return session
+}
+
+func (s *sessionFlags) options(args []string) golox.Options {
+	options := golox.Options{MaxSteps: s.maxSteps, Args: args, Integers: s.integers}
+
+	if s.trace {
+		options.Trace = os.Stderr
+	}
+
+	return options
+}
+
+// runCommand runs a program: golox run [flags] file.lox [args...] or golox run [flags] -e code [args...]
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	code := flags.String("e", "", "run the `code` instead of a file")
+	session := addSessionFlags(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox run [flags] file.lox [args...]\n       golox run [flags] -e code [args...]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	scriptArgs := flags.Args()
+
+	if *code == "" {
+		if flags.NArg() == 0 {
+			flags.Usage()
+			return exitUsage
+		}
+
+		scriptArgs = scriptArgs[1:]
+	}
+
+	lox, closeFile, exit := openSource(*code, flags.Arg(0))
+
+	if lox == nil {
+		return exit
+	}
+	defer closeFile()
+
+	lox.SetOptions(session.options(scriptArgs))
+
+	err := lox.Run(false)
+
+	if err != nil {
+		return exitCode(err)
+	}
+
+	return 0
+}
+
+func replCommand(args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ContinueOnError)
+	session := addSessionFlags(flags)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox repl [flags] [args...]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	lox := golox.New(bufio.NewReader(os.Stdin))
+	lox.SetOptions(session.options(flags.Args()))
+	lox.EnableLineEditing(os.Stdin, os.Stdout)
+
+	err := lox.Run(true)
+
+	if err != nil {
+		return exitCode(err)
+	}
+
+	return 0
+}
+
+// tokensCommand prints the tokens of a file: golox tokens file.lox or golox tokens -e code
+func tokensCommand(args []string) int {
+	flags := flag.NewFlagSet("tokens", flag.ContinueOnError)
+	code := flags.String("e", "", "scan the `code` instead of a file")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox tokens [-e code] [file.lox]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil || (*code == "") != (flags.NArg() == 1) {
+		flags.Usage()
+		return exitUsage
+	}
+
+	lox, closeFile, exit := openSource(*code, flags.Arg(0))
+
+	if lox == nil {
+		return exit
+	}
+	defer closeFile()
+
+	err := lox.PrintTokens()
+
+	if err != nil {
+		return exitCode(err)
+	}
+
+	return 0
+}
+
+// checkCommand reports the compile errors of the files: golox check file.lox...
+func checkCommand(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox check file.lox...\n")
+		flags.PrintDefaults()
 	}
 
-	if len(args) == 1 {
-		file, err := os.Open(args[0])
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
+	}
+
+	exit := 0
+
+	for _, name := range flags.Args() {
+		lox, closeFile, fileExit := openSource("", name)
+
+		if lox == nil {
+			exit = fileExit
+			continue
+		}
+
+		err := lox.Check()
+		closeFile()
 
 		if err != nil {
-			fmt.Printf("error opening file %s, %v\n", args[0], err)
-			os.Exit(1)
+			exit = exitCode(err)
 		}
-		defer file.Close()
+	}
+
+	return exit
+}
+
+// openSource returns a Lox that reads the code, or the file if there's no code.
+// If the file can't be opened, the error is printed and the exit code is returned instead.
+func openSource(code string, name string) (*golox.Lox, func(), int) {
+	if code != "" {
+		return golox.NewFromFile("-e", bufio.NewReader(strings.NewReader(code))), func() {}, 0
+	}
+
+	file, err := os.Open(name)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening file %s, %v\n", name, err)
+		return nil, nil, exitIOErr
+	}
+
+	return golox.NewFromFile(name, bufio.NewReader(file)), func() { file.Close() }, 0
+}
+
+// astCommand prints the syntax tree of a file: golox ast [-tree] file.lox or golox ast [-tree] -e code
+func astCommand(args []string) int {
+	flags := flag.NewFlagSet("ast", flag.ContinueOnError)
+	tree := flags.Bool("tree", false, "print an indented tree instead of S-expressions")
+	code := flags.String("e", "", "parse the `code` instead of a file")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox ast [-tree] [-e code] [file.lox]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil || (*code == "") != (flags.NArg() == 1) {
+		flags.Usage()
+		return exitUsage
+	}
+
+	lox, closeFile, exit := openSource(*code, flags.Arg(0))
+
+	if lox == nil {
+		return exit
+	}
+	defer closeFile()
 
-		ioReader = bufio.NewReader(file)
+	err := lox.PrintAst(*tree)
+
+	if err != nil {
+		return exitCode(err)
+	}
+
+	return 0
+}
+
+// fmtCommand formats the files: golox fmt [-w] [-d] file.lox...
+// Without flags the formatted source is printed.
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := flags.Bool("w", false, "write the result to the file instead of printing it")
+	showDiff := flags.Bool("d", false, "print the diff between the file and the formatted source")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox fmt [-w] [-d] file.lox...\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		flags.Usage()
+		return exitUsage
 	}
 
-	if len(args) > 1 {
-		fmt.Printf("unsupported number of arguments, should be 0 or 1\n")
-		os.Exit(1)
+	code := 0
+
+	// a broken file doesn't stop the others from being formatted
+	for _, name := range flags.Args() {
+		if fileCode := formatFile(name, *write, *showDiff); fileCode != 0 {
+			code = fileCode
+		}
 	}
 
-	lox := golox.New(ioReader)
+	return code
+}
 
-	err := lox.Run(len(args) == 0)
+func formatFile(name string, write bool, showDiff bool) int {
+	lox, closeFile, exit := openSource("", name)
+
+	if lox == nil {
+		return exit
+	}
+
+	formatted, err := lox.Format()
+	closeFile()
 
 	if err != nil {
-		fmt.Printf("unexpected error %v\n", err)
-		os.Exit(1)
+		return exitCode(err)
+	}
+
+	source := lox.Source()
+
+	if showDiff {
+		fmt.Print(diff(name+".orig", name, source, formatted))
 	}
 
-	os.Exit(0)
+	if write && formatted != source {
+		err = os.WriteFile(name, []byte(formatted), 0o644)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error writing file %s, %v\n", name, err)
+			return exitIOErr
+		}
+	}
+
+	if !write && !showDiff {
+		fmt.Print(formatted)
+	}
+
+	return 0
+}
+
+// exitCode tells the compile errors apart from the runtime errors. Both are already
+// reported by Lox, any other error is unexpected and is printed here.
+func exitCode(err error) int {
+	var scanErr *golox.ScanError
+	var parseErrs golox.ParseErrors
+	var runtimeErr *golox.RuntimeError
+	var stepLimitErr *golox.StepLimitError
+
+	switch {
+	case errors.As(err, &scanErr), errors.As(err, &parseErrs):
+		return exitDataErr
+	case errors.As(err, &runtimeErr), errors.As(err, &stepLimitErr):
+		return exitSoftware
+	}
+
+	fmt.Fprintf(os.Stderr, "unexpected error %v\n", err)
+
+	if isIOError(err) {
+		return exitIOErr
+	}
+
+	return exitSoftware
+}
+
+// isIOError checks if the error comes from reading or writing a file, eg. the source or stdout
+func isIOError(err error) bool {
+	var pathErr *fs.PathError
+	var syscallErr *os.SyscallError
+
+	return errors.As(err, &pathErr) || errors.As(err, &syscallErr) || errors.Is(err, io.ErrUnexpectedEOF)
 }
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	exitTests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "write failure", err: &fs.PathError{Op: "write", Path: "/dev/stdout", Err: errors.New("broken pipe")}, expected: exitIOErr},
+		{name: "wrapped syscall error", err: fmt.Errorf("reading: %w", os.NewSyscallError("read", errors.New("bad"))), expected: exitIOErr},
+		{name: "other error", err: errors.New("something else"), expected: exitSoftware},
+	}
+
+	for _, tt := range exitTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.expected {
+				t.Errorf("got %d, expected %d", got, tt.expected)
+			}
+		})
+	}
+}
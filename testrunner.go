@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// the comments that describe what a test file has to do when it's run
+const (
+	// expectOutput is followed by a line the program prints, the lines are expected in order
+	expectOutput = "// expect: "
+	// expectError is followed by a part of the error message, the program has to fail
+	expectError = "// expect error: "
+)
+
+type expectations struct {
+	output []string
+	errors []string
+}
+
+func (e expectations) empty() bool {
+	return len(e.output) == 0 && len(e.errors) == 0
+}
+
+// testCommand runs the test files: golox test [-max-steps n] [file.lox|dir...]
+// The directories are searched for .lox files, the files without expectations are skipped.
+func testCommand(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	maxSteps := flags.Int("max-steps", 0, "stop each test after that many statements, 0 means no limit")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: golox test [-max-steps n] [file.lox|dir...]\n")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	paths := flags.Args()
+
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	files, err := testFiles(paths)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error finding the test files, %v\n", err)
+		return exitIOErr
+	}
+
+	// the tests are run by this same binary, each in its own process
+	executable, err := os.Executable()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error finding the golox executable, %v\n", err)
+		return exitIOErr
+	}
+
+	passed, failed := 0, 0
+
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading file %s, %v\n", file, err)
+			return exitIOErr
+		}
+
+		expected := parseExpectations(string(source))
+
+		if expected.empty() {
+			continue
+		}
+
+		var stdout, stderr bytes.Buffer
+
+		cmd := exec.Command(executable, "run", "-max-steps", strconv.Itoa(*maxSteps), file)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		runErr := cmd.Run()
+
+		if _, ok := runErr.(*exec.ExitError); runErr != nil && !ok {
+			fmt.Fprintf(os.Stderr, "error running %s, %v\n", file, runErr)
+			return exitIOErr
+		}
+
+		problems := checkExpectations(expected, stdout.String(), stderr.String(), runErr != nil)
+
+		if len(problems) == 0 {
+			passed++
+			fmt.Printf("ok   %s\n", file)
+
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL %s\n", file)
+
+		for _, problem := range problems {
+			fmt.Printf("     %s\n", problem)
+		}
+	}
+
+	fmt.Printf("%d passed, %d failed\n", passed, failed)
+
+	if failed > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// testFiles returns the files and the .lox files inside the directories, in lexical order
+func testFiles(paths []string) ([]string, error) {
+	files := []string{}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && filepath.Ext(file) == ".lox" {
+				files = append(files, file)
+			}
+
+			return err
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+func parseExpectations(source string) expectations {
+	expected := expectations{}
+
+	for _, line := range strings.Split(source, "\n") {
+		line = strings.TrimRight(line, "\r")
+
+		if _, output, ok := strings.Cut(line, expectOutput); ok {
+			expected.output = append(expected.output, output)
+		}
+
+		if _, message, ok := strings.Cut(line, expectError); ok {
+			expected.errors = append(expected.errors, message)
+		}
+	}
+
+	return expected
+}
+
+// checkExpectations compares the result of a run with the expectations, it returns what didn't match
+func checkExpectations(expected expectations, stdout string, stderr string, failed bool) []string {
+	problems := []string{}
+	output := splitLines(stdout)
+
+	for i, line := range expected.output {
+		if i >= len(output) {
+			problems = append(problems, fmt.Sprintf("expected %q, got no more output", line))
+			break
+		}
+
+		if output[i] != line {
+			problems = append(problems, fmt.Sprintf("expected %q, got %q", line, output[i]))
+		}
+	}
+
+	if len(output) > len(expected.output) {
+		problems = append(problems, fmt.Sprintf("unexpected output %q", output[len(expected.output)]))
+	}
+
+	if len(expected.errors) == 0 && failed {
+		problems = append(problems, fmt.Sprintf("unexpected error %s", strings.TrimSpace(stderr)))
+	}
+
+	if len(expected.errors) > 0 && !failed {
+		problems = append(problems, "expected an error, the program succeeded")
+	}
+
+	for _, message := range expected.errors {
+		if failed && !strings.Contains(stderr, message) {
+			problems = append(problems, fmt.Sprintf("expected the error %q, got %s", message, strings.TrimSpace(stderr)))
+		}
+	}
+
+	return problems
+}
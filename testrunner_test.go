@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseExpectations(t *testing.T) {
+	source := "print 1; // expect: 1\nprint \"a b\"; // expect: a b\nprint x; // expect error: undefined variable\n"
+
+	got := parseExpectations(source)
+	expected := expectations{output: []string{"1", "a b"}, errors: []string{"undefined variable"}}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got %v, expected %v", got, expected)
+	}
+}
+
+func TestCheckExpectations(t *testing.T) {
+	checkTests := []struct {
+		name     string
+		expected expectations
+		stdout   string
+		stderr   string
+		failed   bool
+		problems int
+	}{
+		{"passed", expectations{output: []string{"1", "2"}}, "1\n2\n", "", false, 0},
+		{"wrong output", expectations{output: []string{"1", "2"}}, "1\n3\n", "", false, 1},
+		{"missing output", expectations{output: []string{"1", "2"}}, "1\n", "", false, 1},
+		{"extra output", expectations{output: []string{"1"}}, "1\n2\n", "", false, 1},
+		{"unexpected error", expectations{output: []string{"1"}}, "1\n", "boom", true, 1},
+		{"expected error", expectations{errors: []string{"boom"}}, "", "error: boom", true, 0},
+		{"wrong error", expectations{errors: []string{"boom"}}, "", "error: bang", true, 1},
+		{"missing error", expectations{errors: []string{"boom"}}, "", "", false, 1},
+	}
+
+	for _, tt := range checkTests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := checkExpectations(tt.expected, tt.stdout, tt.stderr, tt.failed)
+
+			if len(problems) != tt.problems {
+				t.Errorf("got problems %v, expected %d", problems, tt.problems)
+			}
+		})
+	}
+}